@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+
+	"github.com/bballant/modir/pkg/lineup"
+	"golang.org/x/image/font"
+)
+
+// Frame timing for the animation, in hundredths of a second.
+const (
+	gifHoldDelay = 150 // showing a period's lineup
+	gifStepDelay = 8   // each step of the move to the next lineup
+	gifSteps     = 12
+)
+
+// writeAnimation writes an animated GIF that shows each period's lineup in
+// turn, with players sliding to their new positions, subs fading in and the
+// players coming off fading out.
+func writeAnimation(path string, s *lineup.Schedule, field Field) error {
+	width, height, header := 400, 300, 40
+	lineColor := color.Black
+	playerColor := color.Gray{Y: 128}
+	playerRadius := 10
+
+	c := &pngCanvas{faces: map[float64]font.Face{}}
+	var delays []int
+
+	fieldX, fieldY, fieldW, fieldH := fitField(field, 0, header, width, height)
+	positions := lineup.Positions(fieldX, fieldY, fieldW, fieldH, s.Formation)
+
+	frame := func(i int, delay int) {
+		c.NewPage(width, height+header)
+		p := s.Periods[i]
+		drawChanges(c, 10, 26, []string{fmt.Sprintf("Period %d  %s-%s", i+1,
+			lineup.FormatClock(p.Start), lineup.FormatClock(p.End))})
+		drawField(c, field, fieldX, fieldY, fieldW, fieldH, lineColor, 3)
+		delays = append(delays, delay)
+	}
+
+	for i, p := range s.Periods {
+		frame(i, gifHoldDelay)
+		drawPlayers(c, playerColor, playerRadius, positions, p.Players)
+		if i+1 == len(s.Periods) {
+			break
+		}
+
+		next := s.Periods[i+1].Players
+		for step := 1; step <= gifSteps; step++ {
+			t := float64(step) / float64(gifSteps)
+			frame(i, gifStepDelay)
+			for from, name := range p.Players {
+				to := indexOf(next, name)
+				if to < 0 {
+					// Coming off: fade out where they were
+					drawPlayer(c, fade(playerColor, 1-t), playerRadius, positions[from], name, t < 0.5)
+					continue
+				}
+				a, b := positions[from], positions[to]
+				pos := lineup.Position{
+					Symbol: b.Symbol,
+					X:      a.X + int(float64(b.X-a.X)*t),
+					Y:      a.Y + int(float64(b.Y-a.Y)*t),
+				}
+				drawPlayer(c, playerColor, playerRadius, pos, name, true)
+			}
+			for to, name := range next {
+				if indexOf(p.Players, name) < 0 {
+					// Going on: fade in at their position
+					drawPlayer(c, fade(playerColor, t), playerRadius, positions[to], name, t >= 0.5)
+				}
+			}
+		}
+	}
+
+	// Everything is drawn in grays, so each pixel's gray level can be used
+	// as its index in a gray palette without searching for the nearest color.
+	grays := make(color.Palette, 256)
+	for i := range grays {
+		grays[i] = color.Gray{Y: uint8(i)}
+	}
+	anim := &gif.GIF{}
+	for i, img := range c.pages {
+		frame := image.NewPaletted(img.Bounds(), grays)
+		for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+			for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+				frame.SetColorIndex(x, y, color.GrayModel.Convert(img.RGBAAt(x, y)).(color.Gray).Y)
+			}
+		}
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, delays[i])
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := gif.EncodeAll(f, anim); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// drawPlayer draws one player the way drawPlayers does, leaving out the name
+// while they are too faint to read.
+func drawPlayer(c lineup.Canvas, col color.Color, r int, pos lineup.Position, name string, named bool) {
+	if !named {
+		name = ""
+	}
+	drawPlayers(c, col, r, []lineup.Position{pos}, []string{name})
+}
+
+// fade mixes col with the white background, from white at 0 to col at 1.
+func fade(col color.Color, amount float64) color.Color {
+	r, g, b, _ := col.RGBA()
+	mix := func(v uint32) uint8 {
+		return uint8(255 - amount*(255-float64(v>>8)))
+	}
+	return color.RGBA{R: mix(r), G: mix(g), B: mix(b), A: 255}
+}
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name && name != "" {
+			return i
+		}
+	}
+	return -1
+}
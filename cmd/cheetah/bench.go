@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bballant/modir/pkg/lineup"
+)
+
+// bench lists the players in roster who aren't in lineup.
+func bench(roster, lineup []string) []string {
+	var sitting []string
+	for _, name := range roster {
+		if !contains(lineup, name) {
+			sitting = append(sitting, name)
+		}
+	}
+	return sitting
+}
+
+// benchStreak is a run of periods, from first to last, that a player sits
+// out in a row.
+type benchStreak struct {
+	player      string
+	first, last int
+}
+
+func (b benchStreak) String() string {
+	return fmt.Sprintf("%s (periods %d-%d)", b.player, b.first+1, b.last+1)
+}
+
+// benchStreaks finds every time a player in roster sits out two or more
+// periods in a row, in the order the streaks start.
+func benchStreaks(s *lineup.Schedule, roster []string) []benchStreak {
+	var streaks []benchStreak
+	for i := range s.Periods {
+		for _, name := range bench(roster, s.Periods[i].Players) {
+			if i > 0 && !contains(s.Periods[i-1].Players, name) {
+				continue // already counted from an earlier period
+			}
+			last := i
+			for last+1 < len(s.Periods) && !contains(s.Periods[last+1].Players, name) {
+				last++
+			}
+			if last > i {
+				streaks = append(streaks, benchStreak{name, i, last})
+			}
+		}
+	}
+	return streaks
+}
+
+// wrapWords joins words with separator into lines of at most width
+// characters, starting the first line with prefix.
+func wrapWords(prefix string, words []string, separator string, width int) []string {
+	var lines []string
+	line := prefix
+	for i, word := range words {
+		if i < len(words)-1 {
+			word += separator
+		}
+		if len(line)+len(strings.TrimRight(word, " ")) > width && strings.TrimSpace(line) != strings.TrimSpace(prefix) {
+			lines = append(lines, strings.TrimRight(line, " "))
+			line = strings.Repeat(" ", len(prefix))
+		}
+		line += word
+	}
+	return append(lines, strings.TrimRight(line, " "))
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bballant/modir/pkg/lineup"
+	"github.com/goki/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+const fontFile = "/usr/share/fonts/truetype/cousine/Cousine Bold Italic Nerd Font Complete.ttf"
+
+// newCanvas returns a canvas for format that writes to the file name.
+func newCanvas(format, name, paper string) (lineup.Canvas, error) {
+	switch format {
+	case "png":
+		return &pngCanvas{name: name, faces: map[float64]font.Face{}}, nil
+	case "pdf":
+		return newPDFCanvas(name, paper)
+	case "svg":
+		return &svgCanvas{name: name}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q (want png, pdf or svg)", format)
+	}
+}
+
+// pngCanvas draws pages as images and writes each one to its own PNG file.
+type pngCanvas struct {
+	name  string
+	pages []*image.RGBA
+	img   *image.RGBA
+	font  *truetype.Font
+	faces map[float64]font.Face
+}
+
+func (c *pngCanvas) NewPage(width, height int) {
+	c.img = image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(c.img, c.img.Bounds(), &image.Uniform{color.White}, image.ZP, draw.Src)
+	c.pages = append(c.pages, c.img)
+}
+
+func (c *pngCanvas) Line(col color.Color, thickness, x1, y1, x2, y2 int) {
+	drawThickLine(c.img, col, thickness, x1, y1, x2, y2)
+}
+
+func (c *pngCanvas) Circle(col color.Color, x, y, r int, filled bool) {
+	drawCircle(c.img, col, x, y, r, filled)
+}
+
+func (c *pngCanvas) Arc(col color.Color, x, y, r int, start, end float64) {
+	drawArc(c.img, col, x, y, r, start, end)
+}
+
+func (c *pngCanvas) Rect(col color.Color, x, y, width, height int) {
+	draw.Draw(c.img, image.Rect(x, y, x+width, y+height), &image.Uniform{col}, image.ZP, draw.Over)
+}
+
+func (c *pngCanvas) Text(size float64, text string, x, y int) {
+	d := &font.Drawer{
+		Dst:  c.img,
+		Src:  image.NewUniform(color.Black),
+		Face: c.face(size),
+	}
+
+	d.Dot = fixed.Point26_6{
+		X: fixed.I(x),
+		Y: fixed.I(y),
+	}
+	d.DrawString(text)
+}
+
+func (c *pngCanvas) face(size float64) font.Face {
+	if face, ok := c.faces[size]; ok {
+		return face
+	}
+
+	if c.font == nil {
+		fontBytes, err := os.ReadFile(fontFile)
+		if err != nil {
+			panic(err)
+		}
+		c.font, err = truetype.Parse(fontBytes)
+		if err != nil {
+			panic(err)
+		}
+	}
+	face := truetype.NewFace(c.font, &truetype.Options{Size: size})
+	c.faces[size] = face
+	return face
+}
+
+func (c *pngCanvas) Close() error {
+	for i, img := range c.pages {
+		f, err := os.Create(pageFileName(c.name, i, len(c.pages)))
+		if err != nil {
+			return err
+		}
+		if err := png.Encode(f, img); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pageFileName numbers the pages of a multi-page schedule, so
+// soccer_fields.png becomes soccer_fields_1.png, soccer_fields_2.png and so
+// on. A single page keeps the name as given.
+func pageFileName(name string, page, pages int) string {
+	if pages == 1 {
+		return name
+	}
+	ext := filepath.Ext(name)
+	return fmt.Sprintf("%s_%d%s", strings.TrimSuffix(name, ext), page+1, ext)
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bballant/modir/pkg/lineup"
+)
+
+func runCheck(args []string) {
+	flags := flag.NewFlagSet("cheetah check", flag.ExitOnError)
+	game := addGameFlags(flags)
+	rosterFile := flags.String("roster", "", "Roster file, to find players who never play and check who may play in goal")
+	keeper := addKeeperFlags(flags)
+	strength := addStrengthFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: cheetah check [flags] [schedule.csv]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	name, in := "stdin", io.Reader(os.Stdin)
+	if flags.NArg() > 0 {
+		name = flags.Arg(0)
+		f, err := os.Open(name)
+		if err != nil {
+			fatal(err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	schedule, roster, problems, err := validate(in, game, *rosterFile, keeper, strength)
+	if err != nil {
+		fatal(fmt.Errorf("%s: %v", name, err))
+	}
+	if balance := strength.rules(roster); balance.rated() && schedule != nil {
+		strengths, average := balance.periods(schedule)
+		text := make([]string, len(strengths))
+		for i, s := range strengths {
+			text[i] = fmt.Sprintf("%.1f", s)
+		}
+		fmt.Printf("%s: strength by period %s (average %.1f)\n", name, strings.Join(text, " "), average)
+	}
+	if len(problems) > 0 {
+		for _, p := range problems {
+			fmt.Printf("%s: %s\n", name, p.describe(schedule))
+		}
+		os.Exit(1)
+	}
+	fmt.Printf("%s: ok, %d periods, %d players\n", name, len(schedule.Periods), len(schedule.Players()))
+}
+
+// validate reads a schedule, and the roster file if there is one, and
+// looks for everything wrong with the schedule, including periods much
+// stronger or weaker than the rest when the roster has skill ratings. When
+// rows have the wrong number of columns there is no schedule, just a
+// problem for each of them.
+func validate(r io.Reader, game *gameFlags, rosterFile string, keeper *keeperFlags, strength *strengthFlags) (*lineup.Schedule, []Player, []problem, error) {
+	rows, err := lineup.ReadRows(r)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if bad := checkRows(rows); len(bad) > 0 {
+		return nil, nil, bad, nil
+	}
+	schedule, err := game.schedule(rows)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var roster []Player
+	if rosterFile != "" {
+		roster, err = readRoster(rosterFile)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	rules, err := keeper.rules(schedule.Formation, roster)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	problems := lintSchedule(schedule, roster)
+	problems = append(problems, rules.check(schedule)...)
+	return schedule, roster, append(problems, strength.rules(roster).check(schedule)...), nil
+}
+
+// checkRows finds the rows that don't have as many columns as the header.
+func checkRows(rows [][]string) []problem {
+	var bad []problem
+	for i := 1; i < len(rows); i++ {
+		if len(rows[i]) != len(rows[0]) {
+			bad = append(bad, problem{
+				period: -1,
+				slot:   -1,
+				text:   fmt.Sprintf("line %d: has %d columns, want %d", i+1, len(rows[i]), len(rows[0])),
+				severe: true,
+			})
+		}
+	}
+	return bad
+}
+
+// lintSchedule finds players listed more than once in a period, names that
+// appear only once and so may be misspelled, and players on the roster who
+// never play.
+func lintSchedule(s *lineup.Schedule, roster []Player) []problem {
+	var problems []problem
+
+	periods := map[string]int{}
+	for i, p := range s.Periods {
+		slotOf := map[string]int{}
+		for slot, name := range p.Players {
+			if name == "" {
+				continue
+			}
+			first, ok := slotOf[name]
+			if !ok {
+				slotOf[name] = slot
+				periods[name]++
+				continue
+			}
+			problems = append(problems, problem{
+				period: i,
+				slot:   slot,
+				text: fmt.Sprintf("%s is in both %s and %s", name,
+					s.Formation.Slots[first].Symbol, s.Formation.Slots[slot].Symbol),
+				label:  name + " listed twice",
+				severe: true,
+			})
+		}
+	}
+
+	// A player who is only in one period of a longer game is more likely a
+	// typo than a real lineup.
+	if len(s.Periods) > 2 {
+		for i, p := range s.Periods {
+			for slot, name := range p.Players {
+				if name == "" || periods[name] != 1 {
+					continue
+				}
+				text := fmt.Sprintf("%s only plays in this period", name)
+				if like := similarName(name, periods); like != "" {
+					text += fmt.Sprintf(", did you mean %s?", like)
+				}
+				problems = append(problems, problem{period: i, slot: slot, text: text, label: name + " only here?"})
+			}
+		}
+	}
+
+	for _, p := range roster {
+		if periods[p.name] == 0 {
+			problems = append(problems, problem{period: -1, slot: -1, text: p.name + " is on the roster but never plays"})
+		}
+	}
+
+	return problems
+}
+
+// similarName finds a name in periods, other than name itself, that is one
+// or two edits away from it.
+func similarName(name string, periods map[string]int) string {
+	best, bestDist := "", 3
+	for other, n := range periods {
+		if other == name || n < 2 {
+			continue
+		}
+		d := editDistance(strings.ToLower(name), strings.ToLower(other))
+		if d < bestDist || d == bestDist && other < best {
+			best, bestDist = other, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// problem is something wrong with a period of a schedule, about the player
+// in slot, or the whole period when slot is -1. Problems with no period are
+// about the whole schedule or a line of the input, and say so themselves.
+// The label is a short form of the text to mark the field diagram with.
+// Severe problems stop the schedule being drawn.
+type problem struct {
+	period int
+	slot   int
+	text   string
+	label  string
+	severe bool
+}
+
+// describe gives the problem with the line of the input it came from.
+func (p problem) describe(s *lineup.Schedule) string {
+	switch {
+	case p.period < 0:
+		return p.text
+	case s.Periods[p.period].Line > 0:
+		return fmt.Sprintf("line %d: %s", s.Periods[p.period].Line, p.text)
+	default:
+		return fmt.Sprintf("period %d: %s", p.period+1, p.text)
+	}
+}
+
+// warn prints the problems with s on stderr, and reports whether any of
+// them are severe.
+func warn(s *lineup.Schedule, problems []problem) bool {
+	severe := false
+	for _, p := range problems {
+		kind := "warning"
+		if p.severe {
+			kind, severe = "error", true
+		}
+		fmt.Fprintf(os.Stderr, "%s: %s\n", kind, p.describe(s))
+	}
+	return severe
+}
@@ -1,291 +1,237 @@
 package main
 
 import (
-	"encoding/csv"
 	"flag"
 	"fmt"
 	"image"
 	"image/color"
-	"image/draw"
-	"image/png"
-	"io/ioutil"
+	"io"
 	"math"
 	"os"
-	"strconv"
+	"time"
 
-	"github.com/goki/freetype/truetype"
-	"golang.org/x/image/font"
-	"golang.org/x/image/font/basicfont"
-	"golang.org/x/image/math/fixed"
+	"github.com/bballant/modir/pkg/lineup"
 )
 
 func main() {
-	gameTime := flag.Int("t", 52, "Length of time in minutes for the game")
-	formation := flag.Int("f", 322, "Formation of the game")
-	flag.Parse()
-
-	width, height := 400, 300
-	fieldColor := color.White
-	lineColor := color.Black
-	playerColor := color.Gray{Y: 128}
-	lineThickness := 3
-	changesTextOffsetX := 300
-	summaryTextOffsetY := 50
-
-	r := csv.NewReader(os.Stdin)
-	rows, err := r.ReadAll()
-	if err != nil {
-		panic(err)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "plan":
+			runPlan(os.Args[2:])
+			return
+		case "check":
+			runCheck(os.Args[2:])
+			return
+		case "replan":
+			runReplan(os.Args[2:])
+			return
+		case "live":
+			runLive(os.Args[2:])
+			return
+		case "sheets":
+			runSheets(os.Args[2:])
+			return
+		case "season":
+			runSeason(os.Args[2:])
+			return
+		case "tidy":
+			runTidy(os.Args[2:])
+			return
+		}
 	}
+	runRender(os.Args[1:])
+}
 
-	maxImages := 8
-	imagesPerCol := 4
-	cols := 2
-
-	imgWidth := width*cols + changesTextOffsetX*cols
-	imgHeight := height*imagesPerCol + summaryTextOffsetY
-	img := image.NewRGBA(image.Rect(0, 0, imgWidth, imgHeight))
-
-	draw.Draw(img, img.Bounds(), &image.Uniform{fieldColor}, image.ZP, draw.Src)
-
-	playerCounts := map[string]int{}
-
-	for i, row := range rows {
-		if i == 0 || i > maxImages {
-			continue // skip header and limit to a maximum of 8 images
+func runRender(args []string) {
+	flags := flag.NewFlagSet("cheetah", flag.ExitOnError)
+	game := addGameFlags(flags)
+	rosterFile := flags.String("roster", "", "Roster file, for the bench, to find players who never play and check who may play in goal")
+	keeper := addKeeperFlags(flags)
+	strength := addStrengthFlags(flags)
+	reportFile := flags.String("report", "", "File to export minutes per player per position to (.csv or .json)")
+	timelineFile := flags.String("timeline", "", "File to draw a chart of each player's time on the field to (.png, .pdf or .svg)")
+	heatmapFile := flags.String("heatmap", "", "File to draw a table of each player's minutes in each position to (.png, .pdf or .svg)")
+	gifFile := flags.String("gif", "", "File to write an animated GIF of the lineup changes to")
+	icsFile := flags.String("ics", "", "File to write an iCalendar event for each substitution to; needs -kickoff")
+	kickoff := flags.String("kickoff", "", "Local date and time the game starts, like \"2026-10-18 09:00\", for -ics")
+	breakLength := flags.Float64("break", 5, "Minutes between halves or quarters, for -ics")
+	out := addRenderFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: cheetah [flags] [schedule]   (reads stdin without a schedule file)")
+		fmt.Fprintln(flags.Output(), "       cheetah plan|replan|check|live|sheets|season|tidy [flags] ...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	var kickoffTime time.Time
+	if *icsFile != "" {
+		var err error
+		kickoffTime, err = time.ParseInLocation(kickoffLayout, *kickoff, time.Local)
+		if err != nil {
+			fatal(fmt.Errorf("-ics needs -kickoff, like \"2026-10-18 09:00\""))
 		}
+	}
 
-		colIndex := (i - 1) / imagesPerCol
-		rowIndex := (i - 1) % imagesPerCol
-
-		offsetX := colIndex * (width + changesTextOffsetX)
-		offsetY := rowIndex * height
-
-		// Draw field, center circle, and goal boxes
-		drawField(img, offsetX, offsetY, width, height, lineColor, lineThickness)
-
-		playerRadius := 10
-		playerPositions := getPositions(offsetX, offsetY, width, height, *formation)
-
-		playerNames := make([]string, len(row))
-		copy(playerNames, row)
-
-		for _, name := range playerNames {
-			if _, ok := playerCounts[name]; !ok {
-				playerCounts[name] = 0
-			}
-			playerCounts[name]++
+	in := io.Reader(os.Stdin)
+	if flags.NArg() > 0 {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			fatal(err)
 		}
+		defer f.Close()
+		in = f
+	}
+	schedule, roster, problems, err := validate(in, game, *rosterFile, keeper, strength)
+	if err != nil {
+		if flags.NArg() > 0 {
+			err = fmt.Errorf("%s: %v", flags.Arg(0), err)
+		}
+		fatal(err)
+	}
+	if warn(schedule, problems) {
+		fatal(fmt.Errorf("not drawing a schedule with errors"))
+	}
 
-		drawPlayers(img, playerColor, playerRadius, playerPositions, playerNames)
-
-		addLabel(img, strconv.Itoa(i), offsetX+10, offsetY+height-10)
-
-		drawChanges(img, offsetX+width+10, offsetY+height-10, []string{timeInGame(i, len(rows)-1, *gameTime)})
-
-		var subs []string
+	playingTime := lineup.CountPlayingTime(schedule)
 
-		if i == 1 && len(rows) > 2 {
-			nextRow := rows[2]
-			for idx, name := range row {
-				if nextRow[idx] != name {
-					subs = append(subs, nextRow[idx])
-				}
-			}
-		} else if i > 1 {
-			prevRow := rows[i-1]
-			maxNameLen := 0
-			for idx, name := range row {
-				if prevRow[idx] != name {
-					if len(name) > maxNameLen {
-						maxNameLen = len(name)
-					}
-				}
-			}
-			for idx, name := range row {
-				if prevRow[idx] != name {
-					pos := playerPositions[idx].symbol
-					name = fmt.Sprintf("%s %-*s", pos, maxNameLen, name)
-					subs = append(subs, name+" for "+prevRow[idx])
-				}
-			}
-		}
+	var strengths []float64
+	if balance := strength.rules(roster); balance.rated() {
+		strengths, _ = balance.periods(schedule)
+	}
+	if err := out.render(schedule, playerNames(roster), playingTime, problems, strengths); err != nil {
+		fatal(err)
+	}
 
-		if len(subs) > 0 {
-			drawChanges(img, offsetX+width+10, offsetY+20, subs)
+	if *reportFile != "" {
+		if err := writeReport(*reportFile, playingTime); err != nil {
+			fatal(err)
 		}
 	}
 
-	summary := ""
-	overflow := ""
-	term := float64(*gameTime) / float64(len(rows)-1)
-	for name, count := range playerCounts {
-		if len(summary) < 100 {
-			summary = fmt.Sprintf("%s %s %s", summary, name, decimalToTimeString(term*float64(count)))
-		} else {
-			overflow = fmt.Sprintf("%s %s %s", overflow, name, decimalToTimeString(term*float64(count)))
+	names := lineup.MergeNames(schedule.Players(), playerNames(roster))
+	if *timelineFile != "" {
+		if err := out.chart(*timelineFile, func(c lineup.Canvas) { drawTimeline(c, schedule, names) }); err != nil {
+			fatal(err)
 		}
-
 	}
-	drawChanges(img, 5, imgHeight-30, []string{summary})
-	if overflow != "" {
-		drawChanges(img, 5, imgHeight-10, []string{overflow})
+	if *heatmapFile != "" {
+		if err := out.chart(*heatmapFile, func(c lineup.Canvas) { drawHeatmap(c, schedule, names, playingTime) }); err != nil {
+			fatal(err)
+		}
 	}
-
-	fileName := "soccer_fields.png"
-	f, err := os.Create(fileName)
-	if err != nil {
-		panic(err)
+	if *icsFile != "" {
+		if err := writeICS(*icsFile, schedule, kickoffTime, *breakLength); err != nil {
+			fatal(err)
+		}
 	}
-	defer f.Close()
-	png.Encode(f, img)
-}
-
-type Position struct {
-	symbol string
-	x, y   int
-}
-
-func getPositions(offsetX, offsetY, width, height, formation int) []Position {
-	switch formation {
-	case 322:
-		return []Position{
-			{"GK", width/20 + offsetX, height/2 + offsetY},
-			{"LB", width/4 + offsetX, height/4 + offsetY},
-			{"CB", width/4 + offsetX, height/2 + offsetY},
-			{"RB", width/4 + offsetX, height*3/4 + offsetY},
-			{"LM", width*4/8 + offsetX, height*3/8 + offsetY},
-			{"RM", width*4/8 + offsetX, height*5/8 + offsetY},
-			{"LF", width*6/8 + offsetX, height*3/8 + offsetY},
-			{"RF", width*6/8 + offsetX, height*5/8 + offsetY},
+	if *gifFile != "" {
+		field, err := findField(*out.field, schedule.Formation)
+		if err != nil {
+			fatal(err)
 		}
-	case 331:
-		return []Position{
-			{"GK", width/20 + offsetX, height/2 + offsetY},
-			{"LB", width/4 + offsetX, height/2 + offsetY},
-			{"CB", width/4 + offsetX, height/4 + offsetY},
-			{"RB", width/4 + offsetX, height*3/4 + offsetY},
-			{"LM", width*4/8 + offsetX, height/2 + offsetY},
-			{"CM", width*4/8 + offsetX, height/4 + offsetY},
-			{"RM", width*4/8 + offsetX, height*3/4 + offsetY},
-			{"ST", width*6/8 + offsetX, height/2 + offsetY},
+		if err := writeAnimation(*gifFile, schedule, field); err != nil {
+			fatal(err)
 		}
-	default:
-		return []Position{}
 	}
 }
 
-func timeInGame(period int, totalPeriods int, totalTime int) string {
-	timeNum := 0.0
-	if period > 0 {
-		timeNum = float64(period) / float64(totalPeriods)
-	}
-	return decimalToTimeString(timeNum * float64(totalTime))
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "cheetah:", err)
+	os.Exit(1)
 }
 
-func decimalToTimeString(decimal float64) string {
-	hours := int(decimal)
-	minutes := int(math.Round((decimal - float64(hours)) * 60))
-	return fmt.Sprintf("%02d:%02d", hours, minutes)
-}
+// drawField draws the field's markings to scale in the given rectangle,
+// which should have the field's proportions (see fitField).
+func drawField(c lineup.Canvas, field Field, offsetX, offsetY, width, height int, lineColor color.Color, lineThickness int) {
+	scale := float64(width) / field.Length
+	m := func(meters float64) int { return int(math.Round(meters * scale)) }
+	midX, midY := offsetX+width/2, offsetY+height/2
 
-func drawField(img *image.RGBA, offsetX, offsetY, width, height int, lineColor color.Color, lineThickness int) {
 	// Draw field outline
-	drawThickLine(img, lineColor, lineThickness, offsetX+0, offsetY+0, offsetX+width-1, offsetY+0)
-	drawThickLine(img, lineColor, lineThickness, offsetX+width-1, offsetY+0, offsetX+width-1, offsetY+height-1)
-	drawThickLine(img, lineColor, lineThickness, offsetX+width-1, offsetY+height-1, offsetX+0, offsetY+height-1)
-	drawThickLine(img, lineColor, lineThickness, offsetX+0, offsetY+height-1, offsetX+0, offsetY+0)
-
-	// Draw center circle
-	drawCircle(img, lineColor, width/2+offsetX, height/2+offsetY, height/5, false)
-
-	// Draw center line
-	drawLine(img, lineColor, width/2+offsetX, offsetY, width/2+offsetX, height+offsetY)
-
-	// left goal box
-	drawLine(img, lineColor, offsetX, offsetY+60, offsetX+60, offsetY+60)
-	drawLine(img, lineColor, offsetX+60, offsetY+60, offsetX+60, offsetY+height-60)
-	drawLine(img, lineColor, offsetX, offsetY+height-60, offsetX+60, offsetY+height-60)
+	c.Line(lineColor, lineThickness, offsetX+0, offsetY+0, offsetX+width-1, offsetY+0)
+	c.Line(lineColor, lineThickness, offsetX+width-1, offsetY+0, offsetX+width-1, offsetY+height-1)
+	c.Line(lineColor, lineThickness, offsetX+width-1, offsetY+height-1, offsetX+0, offsetY+height-1)
+	c.Line(lineColor, lineThickness, offsetX+0, offsetY+height-1, offsetX+0, offsetY+0)
+
+	// Draw center line, circle and mark
+	c.Line(lineColor, 1, midX, offsetY, midX, offsetY+height)
+	c.Circle(lineColor, midX, midY, m(field.CenterCircle), false)
+	c.Circle(lineColor, midX, midY, 2, true)
+
+	// Corner arcs, clockwise from the top left
+	if field.CornerArc > 0 {
+		r := m(field.CornerArc)
+		c.Arc(lineColor, offsetX, offsetY, r, 0, 90)
+		c.Arc(lineColor, offsetX+width-1, offsetY, r, 90, 180)
+		c.Arc(lineColor, offsetX+width-1, offsetY+height-1, r, 180, 270)
+		c.Arc(lineColor, offsetX, offsetY+height-1, r, 270, 360)
+	}
 
-	// right goal box
-	drawLine(img, lineColor, offsetX+width-60, offsetY+60, offsetX+width, offsetY+60)
-	drawLine(img, lineColor, offsetX+width-60, offsetY+60, offsetX+width-60, offsetY+height-60)
-	drawLine(img, lineColor, offsetX+width-60, offsetY+height-60, offsetX+width, offsetY+height-60)
-}
+	// Each end, measuring out from the goal line at goalX in direction dir.
+	for _, end := range []struct{ goalX, dir int }{{offsetX, 1}, {offsetX + width - 1, -1}} {
+		box := func(depth float64) {
+			x := end.goalX + end.dir*m(depth)
+			top, bottom := midY-m(field.Goal/2+depth), midY+m(field.Goal/2+depth)
+			c.Line(lineColor, 1, end.goalX, top, x, top)
+			c.Line(lineColor, 1, x, top, x, bottom)
+			c.Line(lineColor, 1, end.goalX, bottom, x, bottom)
+		}
 
-func drawChanges(img *image.RGBA, startX, startY int, changes []string) {
-	// Load font
-	fontBytes, err := ioutil.ReadFile("/usr/share/fonts/truetype/cousine/Cousine Bold Italic Nerd Font Complete.ttf")
-	if err != nil {
-		panic(err)
-	}
-	f, err := truetype.Parse(fontBytes)
-	if err != nil {
-		panic(err)
-	}
-	fontFace := truetype.NewFace(f, &truetype.Options{Size: 18})
+		// Goal mouth, drawn thicker just inside the goal line
+		mouthX := end.goalX
+		if end.dir < 0 {
+			mouthX -= lineThickness + 1
+		}
+		c.Line(lineColor, lineThickness+2, mouthX, midY-m(field.Goal/2), mouthX, midY+m(field.Goal/2))
 
-	// Draw changes text
-	d := &font.Drawer{
-		Dst:  img,
-		Src:  image.NewUniform(color.Black),
-		Face: fontFace,
-	}
+		if field.GoalArea > 0 {
+			box(field.GoalArea)
+		}
+		if field.PenaltyArea > 0 {
+			box(field.PenaltyArea)
+
+			spotX := end.goalX + end.dir*m(field.PenaltySpot)
+			c.Circle(lineColor, spotX, midY, 2, true)
+
+			// Penalty arc: the part of the circle round the penalty mark
+			// that is outside the penalty area.
+			outside := field.PenaltyArea - field.PenaltySpot
+			if field.CenterCircle > outside {
+				half := math.Acos(outside/field.CenterCircle) * 180 / math.Pi
+				facing := 0.0
+				if end.dir < 0 {
+					facing = 180
+				}
+				c.Arc(lineColor, spotX, midY, m(field.CenterCircle), facing-half, facing+half)
+			}
+		}
 
-	for i, change := range changes {
-		changeText := fmt.Sprintf("%s", change)
-		d.Dot = fixed.Point26_6{
-			X: fixed.I(startX),
-			Y: fixed.I(startY + i*18),
+		// Build-out line, dashed, halfway between the penalty area and the
+		// halfway line
+		if field.BuildOut {
+			x := end.goalX + end.dir*m((field.PenaltyArea+field.Length/2)/2)
+			for y := offsetY; y < offsetY+height; y += 12 {
+				c.Line(lineColor, 1, x, y, x, y+5)
+			}
 		}
-		d.DrawString(changeText)
 	}
 }
 
-func drawPlayers(img *image.RGBA, c color.Color, r int, pos []Position, names []string) {
-	for i, p := range pos {
-		drawCircle(img, c, p.x, p.y, r, true)
-		addLabel(img, names[i], p.x, p.y)
+func drawChanges(c lineup.Canvas, startX, startY int, changes []string) {
+	for i, change := range changes {
+		c.Text(18, change, startX, startY+i*18)
 	}
 }
 
-func drawLabel(img *image.RGBA, label string, x, y int) {
-	col := color.Black
-	point := fixed.Point26_6{X: fixed.Int26_6(x * 64), Y: fixed.Int26_6(y * 64)}
-
-	d := &font.Drawer{
-		Dst:  img,
-		Src:  image.NewUniform(col),
-		Face: basicfont.Face7x13,
-		Dot:  point,
+func drawPlayers(c lineup.Canvas, col color.Color, r int, pos []lineup.Position, names []string) {
+	for i, p := range pos {
+		c.Circle(col, p.X, p.Y, r, true)
+		addLabel(c, names[i], p.X, p.Y)
 	}
-	d.DrawString(label)
 }
 
-func addLabel(img *image.RGBA, label string, x, y int) {
-	// Load font
-	fontBytes, err := ioutil.ReadFile("/usr/share/fonts/truetype/cousine/Cousine Bold Italic Nerd Font Complete.ttf")
-	if err != nil {
-		panic(err)
-	}
-	f, err := truetype.Parse(fontBytes)
-	if err != nil {
-		panic(err)
-	}
-	fontFace := truetype.NewFace(f, &truetype.Options{Size: 14})
-
-	// Draw label text
-	d := &font.Drawer{
-		Dst:  img,
-		Src:  image.NewUniform(color.Black),
-		Face: fontFace,
-	}
-
-	d.Dot = fixed.Point26_6{
-		X: fixed.I(x),
-		Y: fixed.I(y),
-	}
-	d.DrawString(label)
+func addLabel(c lineup.Canvas, label string, x, y int) {
+	c.Text(14, label, x, y)
 }
 
 func drawThickLine(img *image.RGBA, c color.Color, t, x1, y1, x2, y2 int) {
@@ -317,3 +263,18 @@ func drawCircle(img *image.RGBA, c color.Color, x, y, r int, filled bool) {
 		}
 	}
 }
+
+func drawArc(img *image.RGBA, c color.Color, x, y, r int, start, end float64) {
+	for i := x - r; i <= x+r; i++ {
+		for j := y - r; j <= y+r; j++ {
+			d := (i-x)*(i-x) + (j-y)*(j-y)
+			if d > r*r || d < (r-1)*(r-1) {
+				continue
+			}
+			angle := math.Atan2(float64(j-y), float64(i-x)) * 180 / math.Pi
+			if math.Mod(angle-start+720, 360) <= end-start {
+				img.Set(i, j, c)
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/bballant/modir/pkg/lineup"
+)
+
+// Field is the size of a field and its markings, in meters. The goal and
+// penalty areas reach as far either side of the goal as they do out from
+// the goal line, as in the Laws of the Game.
+type Field struct {
+	Name          string
+	Players       int // most players a side the field is used for
+	Length, Width float64
+	Goal          float64 // width of the goal
+	GoalArea      float64 // depth of the goal area, or 0 for none
+	PenaltyArea   float64 // depth of the penalty area, or 0 for none
+	PenaltySpot   float64 // distance of the penalty mark from the goal line
+	CenterCircle  float64 // radius of the center circle and penalty arc
+	CornerArc     float64
+	BuildOut      bool // build-out lines halfway between the penalty areas and halfway line
+}
+
+// fields are the usual youth and adult field sizes, smallest first. The
+// small-sided ones are in the middle of the ranges US Soccer recommends.
+var fields = []Field{
+	{
+		Name: "4v4", Players: 4,
+		Length: 30, Width: 20,
+		Goal:         1.83,
+		CenterCircle: 3,
+	},
+	{
+		Name: "7v7", Players: 7,
+		Length: 55, Width: 36,
+		Goal:     5.49,
+		GoalArea: 3.66, PenaltyArea: 10.97, PenaltySpot: 7.32,
+		CenterCircle: 5.49,
+		CornerArc:    0.91,
+		BuildOut:     true,
+	},
+	{
+		Name: "9v9", Players: 9,
+		Length: 73, Width: 46,
+		Goal:     6.4,
+		GoalArea: 4.57, PenaltyArea: 12.8, PenaltySpot: 9.14,
+		CenterCircle: 7.32,
+		CornerArc:    0.91,
+	},
+	{
+		Name: "11v11", Players: 11,
+		Length: 105, Width: 68,
+		Goal:     7.32,
+		GoalArea: 5.5, PenaltyArea: 16.5, PenaltySpot: 11,
+		CenterCircle: 9.15,
+		CornerArc:    1,
+	},
+}
+
+// fieldFor picks the smallest field meant for a side of the formation's
+// size, so 8v8 is played on a 9v9 field.
+func fieldFor(formation lineup.Formation) Field {
+	for _, f := range fields {
+		if len(formation.Slots) <= f.Players {
+			return f
+		}
+	}
+	return fields[len(fields)-1]
+}
+
+// findField looks up a field by name, like 7v7 or just 7. An empty name or
+// "auto" picks the field for the formation.
+func findField(name string, formation lineup.Formation) (Field, error) {
+	if name == "" || name == "auto" {
+		return fieldFor(formation), nil
+	}
+	key := strings.ToLower(name)
+	if n, _, found := strings.Cut(key, "v"); found {
+		key = n
+	}
+	var known []string
+	for _, f := range fields {
+		if key == fmt.Sprint(f.Players) {
+			return f, nil
+		}
+		known = append(known, f.Name)
+	}
+	return Field{}, fmt.Errorf("unknown field %q (known fields: auto, %s)", name, strings.Join(known, ", "))
+}
+
+// fitField is the largest rectangle with the field's proportions that fits
+// in the given box, centered in it.
+func fitField(field Field, x, y, width, height int) (int, int, int, int) {
+	scale := math.Min(float64(width)/field.Length, float64(height)/field.Width)
+	w := int(field.Length * scale)
+	h := int(field.Width * scale)
+	return x + (width-w)/2, y + (height-h)/2, w, h
+}
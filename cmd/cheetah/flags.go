@@ -0,0 +1,201 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bballant/modir/pkg/lineup"
+)
+
+// gameFlags are the flags describing the game a schedule is for.
+type gameFlags struct {
+	gameTime       *int
+	formationName  *string
+	formationsFile *string
+	halves         *bool
+	quarters       *bool
+}
+
+func addGameFlags(flags *flag.FlagSet) *gameFlags {
+	return &gameFlags{
+		gameTime:       flags.Int("t", 52, "Length of time in minutes for the game"),
+		formationName:  flags.String("f", "322", "Formation of the game"),
+		formationsFile: flags.String("formations", "", "JSON file of formation definitions to add to the bundled ones"),
+		halves:         flags.Bool("halves", false, "The game is played in two halves"),
+		quarters:       flags.Bool("quarters", false, "The game is played in four quarters"),
+	}
+}
+
+func (g *gameFlags) formation() (lineup.Formation, error) {
+	formations, err := lineup.LoadFormations(*g.formationsFile)
+	if err != nil {
+		return lineup.Formation{}, err
+	}
+	return lineup.FindFormation(formations, *g.formationName)
+}
+
+// length is how long the game lasts, in minutes.
+func (g *gameFlags) length() (float64, error) {
+	if *g.gameTime < 1 {
+		return 0, fmt.Errorf("-t must be at least 1 minute, not %d", *g.gameTime)
+	}
+	return float64(*g.gameTime), nil
+}
+
+// segments is how many halves or quarters the game is played in.
+func (g *gameFlags) segments() (int, error) {
+	switch {
+	case *g.halves && *g.quarters:
+		return 0, fmt.Errorf("-halves and -quarters can't be used together")
+	case *g.halves:
+		return 2, nil
+	case *g.quarters:
+		return 4, nil
+	default:
+		return 1, nil
+	}
+}
+
+// readSchedule reads a schedule for the game from r, in any of the formats
+// lineup.ReadRows understands.
+func (g *gameFlags) readSchedule(r io.Reader) (*lineup.Schedule, error) {
+	rows, err := lineup.ReadRows(r)
+	if err != nil {
+		return nil, err
+	}
+	return g.schedule(rows)
+}
+
+// schedule makes a schedule for the game from the records of a CSV.
+func (g *gameFlags) schedule(rows [][]string) (*lineup.Schedule, error) {
+	formation, err := g.formation()
+	if err != nil {
+		return nil, err
+	}
+	segments, err := g.segments()
+	if err != nil {
+		return nil, err
+	}
+	gameTime, err := g.length()
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := lineup.ParseSchedule(rows, formation, gameTime)
+	if err != nil {
+		return nil, err
+	}
+	schedule.Segments = segments
+
+	return schedule, nil
+}
+
+// readScheduleFile reads a schedule from the named file.
+func (g *gameFlags) readScheduleFile(path string) (*lineup.Schedule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	schedule, err := g.readSchedule(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return schedule, nil
+}
+
+// renderFlags are the flags controlling how a schedule is drawn.
+type renderFlags struct {
+	rowsPerPage *int
+	colsPerPage *int
+	output      *string
+	format      *string
+	paper       *string
+	field       *string
+}
+
+func addRenderFlags(flags *flag.FlagSet) *renderFlags {
+	return &renderFlags{
+		rowsPerPage: flags.Int("rows", 4, "Number of fields per column on each page"),
+		colsPerPage: flags.Int("cols", 2, "Number of columns of fields on each page"),
+		output:      flags.String("o", "", "File to write to (default soccer_fields.png, .pdf or .svg); PNG and SVG pages are numbered when there is more than one"),
+		format:      flags.String("format", "", "Output format: png, pdf or svg (default from the -o file's extension, or png)"),
+		paper:       flags.String("paper", "letter", "Paper size for PDF output: letter or a4"),
+		field:       flags.String("field", "auto", "Field markings to draw: 4v4, 7v7, 9v9, 11v11, or auto for the formation's size"),
+	}
+}
+
+// canvas returns a canvas to draw on, writing to the -o file or to name with
+// the format's extension. Without -format, the format is taken from the -o
+// file's extension.
+func (r *renderFlags) canvas(name string) (lineup.Canvas, error) {
+	if *r.rowsPerPage < 1 || *r.colsPerPage < 1 {
+		return nil, fmt.Errorf("-rows and -cols must be at least 1")
+	}
+	format, err := outputFormat(*r.format, *r.output, canvasFormats, "png")
+	if err != nil {
+		return nil, err
+	}
+	output := *r.output
+	if output == "" {
+		output = name + "." + format
+	}
+	return newCanvas(format, output, *r.paper)
+}
+
+// canvasFormats are the formats that can be drawn, by file extension.
+var canvasFormats = map[string]string{"png": "png", "pdf": "pdf", "svg": "svg"}
+
+// outputFormat is the format to write output in: format when it is given,
+// or else the one in known that the output file's extension names, or else
+// fallback. A format that doesn't match the extension is an error.
+func outputFormat(format, output string, known map[string]string, fallback string) (string, error) {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(output), "."))
+	if implied, ok := known[ext]; ok {
+		if format == "" {
+			return implied, nil
+		}
+		if format != implied {
+			return "", fmt.Errorf("-format %s doesn't match the -o file %s", format, output)
+		}
+	}
+	if format == "" {
+		return fallback, nil
+	}
+	return format, nil
+}
+
+// render draws the schedule's field diagrams and playing time summary,
+// marking any problems found with it, and each period's team strength if
+// there are strengths. The bench is everyone in the schedule and roster who
+// isn't on the field.
+func (r *renderFlags) render(schedule *lineup.Schedule, roster []string, playingTime *lineup.PlayingTime, problems []problem, strengths []float64) error {
+	field, err := findField(*r.field, schedule.Formation)
+	if err != nil {
+		return err
+	}
+	c, err := r.canvas("soccer_fields")
+	if err != nil {
+		return err
+	}
+	roster = lineup.MergeNames(schedule.Players(), roster)
+	renderSchedule(c, schedule, field, roster, playingTime, problems, strengths, *r.rowsPerPage, *r.colsPerPage)
+	return c.Close()
+}
+
+// chart draws a chart with draw to the file at path, in the format its
+// extension names.
+func (r *renderFlags) chart(path string, draw func(c lineup.Canvas)) error {
+	format := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	c, err := newCanvas(format, path, *r.paper)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	draw(c)
+	return c.Close()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/bballant/modir/pkg/lineup"
+)
+
+// drawHeatmap draws a table with a row for each player and a column for
+// each slot of the formation, each cell shaded by how long the player spent
+// in the slot.
+func drawHeatmap(c lineup.Canvas, s *lineup.Schedule, roster []string, playingTime *lineup.PlayingTime) {
+	nameWidth, cellWidth, cellHeight, top := 160, 70, 28, 70
+	symbols := s.Formation.Symbols()
+
+	most := 0.0
+	for _, name := range roster {
+		for _, minutes := range playingTime.BySlot(name) {
+			if minutes > most {
+				most = minutes
+			}
+		}
+	}
+
+	c.NewPage(nameWidth+cellWidth*(len(symbols)+1)+20, top+cellHeight*len(roster)+20)
+	drawChanges(c, 10, 25, []string{"Minutes by position"})
+	for col, symbol := range symbols {
+		addLabel(c, symbol, nameWidth+col*cellWidth+cellWidth/2-4*len(symbol), top-10)
+	}
+	addLabel(c, "Total", nameWidth+len(symbols)*cellWidth+10, top-10)
+
+	for row, name := range roster {
+		y := top + row*cellHeight
+		drawChanges(c, 10, y+20, []string{name})
+		for col, symbol := range symbols {
+			x := nameWidth + col*cellWidth
+			minutes := playingTime.BySlot(name)[symbol]
+			c.Rect(heatColor(minutes, most), x, y, cellWidth, cellHeight)
+			if minutes > 0 {
+				addLabel(c, lineup.FormatClock(minutes), x+12, y+19)
+			}
+		}
+		addLabel(c, lineup.FormatClock(playingTime.Total(name)), nameWidth+len(symbols)*cellWidth+10, y+19)
+	}
+
+	// Grid
+	right := nameWidth + len(symbols)*cellWidth
+	bottom := top + len(roster)*cellHeight
+	for row := 0; row <= len(roster); row++ {
+		c.Line(color.Gray{Y: 160}, 1, nameWidth, top+row*cellHeight, right, top+row*cellHeight)
+	}
+	for col := 0; col <= len(symbols); col++ {
+		c.Line(color.Gray{Y: 160}, 1, nameWidth+col*cellWidth, top, nameWidth+col*cellWidth, bottom)
+	}
+}
+
+// heatColor shades from white for no time to blue for the most time, light
+// enough for black text to stay readable.
+func heatColor(minutes, most float64) color.Color {
+	if most <= 0 {
+		return color.White
+	}
+	f := minutes / most
+	shade := func(to uint8) uint8 { return uint8(255 - f*float64(255-int(to))) }
+	return color.RGBA{R: shade(80), G: shade(140), B: shade(220), A: 255}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bballant/modir/pkg/lineup"
+)
+
+// kickoffLayout is how -kickoff is written, in local time.
+const kickoffLayout = "2006-01-02 15:04"
+
+// writeICS writes an iCalendar file with an event at each substitution,
+// listing the subs, and an alarm a minute before. Breaks between halves or
+// quarters are breakLength minutes long, and push back the subs after them.
+func writeICS(path string, s *lineup.Schedule, kickoff time.Time, breakLength float64) error {
+	var b strings.Builder
+	line := func(format string, args ...interface{}) {
+		b.WriteString(foldICS(fmt.Sprintf(format, args...)))
+		b.WriteString("\r\n")
+	}
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//bballant//cheetah//EN")
+	line("CALSCALE:GREGORIAN")
+	for i := 1; i < len(s.Periods); i++ {
+		p := s.Periods[i]
+		subs := describeSubs(s.Formation, s.Periods[i-1].Players, p.Players)
+		if len(subs) == 0 {
+			continue
+		}
+		for n, sub := range subs {
+			// Drop the padding that lines the subs up in the image
+			subs[n] = strings.Join(strings.Fields(sub), " ")
+		}
+
+		minutes := p.Start + float64(s.SegmentAt(p.Start))*breakLength
+		at := kickoff.Add(time.Duration(minutes * float64(time.Minute))).Round(time.Second).UTC()
+		summary := fmt.Sprintf("Subs for period %d (%s)", i+1, lineup.FormatClock(p.Start))
+		if s.StartsSegment(i) {
+			summary = fmt.Sprintf("Subs for %s, period %d", strings.ToLower(segmentTitle(s, i)), i+1)
+		}
+
+		line("BEGIN:VEVENT")
+		line("UID:%d-period-%d@cheetah", kickoff.Unix(), i+1)
+		line("DTSTAMP:%s", stamp)
+		line("DTSTART:%s", at.Format("20060102T150405Z"))
+		line("DTEND:%s", at.Add(time.Minute).Format("20060102T150405Z"))
+		line("SUMMARY:%s", escapeICS(summary))
+		line("DESCRIPTION:%s", escapeICS(strings.Join(subs, "\n")))
+		line("BEGIN:VALARM")
+		line("TRIGGER:-PT1M")
+		line("ACTION:DISPLAY")
+		line("DESCRIPTION:%s", escapeICS(summary))
+		line("END:VALARM")
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// escapeICS escapes text for an iCalendar TEXT value.
+func escapeICS(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(text)
+}
+
+// foldICS splits a content line longer than 75 bytes into continuation
+// lines starting with a space, without breaking up a UTF-8 character.
+func foldICS(text string) string {
+	var b strings.Builder
+	width := 0
+	for _, r := range text {
+		n := len(string(r))
+		if width+n > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += n
+	}
+	return b.String()
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/bballant/modir/pkg/lineup"
+)
+
+// keeperRules are the league's rules about who plays in goal.
+type keeperRules struct {
+	slot     int               // index of the goalkeeper slot, or -1 if there isn't one
+	banned   map[string]string // players who may not play in goal, and why
+	fullHalf bool              // a keeper stays in goal for a whole half
+}
+
+// keeperFlags are the flags setting the goalkeeper rules.
+type keeperFlags struct {
+	fullHalf *bool
+	ledger   *string
+	game     *string
+	date     *string
+}
+
+func addKeeperFlags(flags *flag.FlagSet) *keeperFlags {
+	return &keeperFlags{
+		fullHalf: flags.Bool("keeper-half", false, "Keepers stay in goal for a whole half"),
+		ledger:   flags.String("ledger", "", "Season ledger; whoever kept goal in the game before this one doesn't this game"),
+		game:     flags.String("game", "", "Name of this game in the ledger, so it isn't taken as the game before"),
+		date:     flags.String("date", "", "Date of this game, YYYY-MM-DD (default its date in the ledger, or today)"),
+	}
+}
+
+// rules puts together the goalkeeper rules for a game in formation, with
+// the players who don't play in goal taken from roster and the ledger.
+func (k *keeperFlags) rules(formation lineup.Formation, roster []Player) (*keeperRules, error) {
+	r := &keeperRules{slot: -1, banned: map[string]string{}, fullHalf: *k.fullHalf}
+	for i, s := range formation.Slots {
+		if s.TeamLine() == "GK" {
+			r.slot = i
+			break
+		}
+	}
+
+	for _, p := range roster {
+		if p.noGK {
+			r.banned[p.name] = "doesn't play in goal"
+		}
+	}
+
+	if *k.ledger != "" {
+		// A ledger that isn't there would quietly drop the rule, so unlike
+		// season add this doesn't start a new one.
+		if _, err := os.Stat(*k.ledger); err != nil {
+			return nil, fmt.Errorf("-ledger: %v", err)
+		}
+		ledger, err := readLedger(*k.ledger)
+		if err != nil {
+			return nil, err
+		}
+		date := *k.date
+		if date != "" {
+			if err := parseDate(date); err != nil {
+				return nil, fmt.Errorf("-date: %v", err)
+			}
+		} else if g := ledger.find(*k.game); g != nil {
+			date = g.Date
+		} else {
+			date = time.Now().Format(dateLayout)
+		}
+		if last := ledger.previous(*k.game, date); last != nil {
+			for name, e := range last.Players {
+				if e.Lines["GK"] > 0 && r.banned[name] == "" {
+					r.banned[name] = fmt.Sprintf("kept goal last game (%s)", last.Name)
+				}
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// canKeep reports whether name may play in goal this game.
+func (r *keeperRules) canKeep(name string) bool {
+	return r.banned[name] == ""
+}
+
+// changesAt reports whether the keeper may change at minute start, given
+// when the second half starts.
+func (r *keeperRules) changesAt(start, halfTime float64) bool {
+	return !r.fullHalf || start == 0 || math.Abs(start-halfTime) < 1e-6
+}
+
+// check finds the periods of s that break the rules.
+func (r *keeperRules) check(s *lineup.Schedule) []problem {
+	if r.slot < 0 {
+		return nil
+	}
+
+	var problems []problem
+	halfTime := s.GameTime / 2
+	if r.fullHalf && !subsAtHalfTime(s) {
+		problems = append(problems, problem{
+			period: -1,
+			slot:   -1,
+			text: fmt.Sprintf("no period starts at half time (%s), so the keeper can never change",
+				lineup.FormatClock(halfTime)),
+		})
+	}
+	for i, p := range s.Periods {
+		keeper := p.Players[r.slot]
+		if keeper == "" {
+			continue
+		}
+		if why := r.banned[keeper]; why != "" {
+			problems = append(problems, problem{
+				period: i,
+				slot:   r.slot,
+				text:   fmt.Sprintf("%s is in goal but %s", keeper, why),
+				label:  "GK " + keeper + " not allowed",
+			})
+		}
+		if i == 0 {
+			continue
+		}
+		prev := s.Periods[i-1].Players[r.slot]
+		if prev != "" && prev != keeper && !r.changesAt(p.Start, halfTime) {
+			half := "first"
+			if p.Start > halfTime {
+				half = "second"
+			}
+			problems = append(problems, problem{
+				period: i,
+				slot:   r.slot,
+				text:   fmt.Sprintf("%s takes over in goal from %s during the %s half", keeper, prev, half),
+				label:  "GK changed mid-half",
+			})
+		}
+	}
+
+	// Keepers who stay for a whole half are meant to swap at half time.
+	if keeper := s.Periods[0].Players[r.slot]; r.fullHalf && keeper != "" && len(s.Periods) > 1 {
+		whole := true
+		for _, p := range s.Periods {
+			if p.Players[r.slot] != keeper {
+				whole = false
+				break
+			}
+		}
+		if whole {
+			problems = append(problems, problem{
+				period: -1,
+				slot:   -1,
+				text:   fmt.Sprintf("%s is in goal for the whole game", keeper),
+			})
+		}
+	}
+	return problems
+}
+
+// subsAtHalfTime reports whether one of the periods of s starts at half
+// time, the only time a keeper who stays for the whole half can change.
+func subsAtHalfTime(s *lineup.Schedule) bool {
+	for _, p := range s.Periods {
+		if math.Abs(p.Start-s.GameTime/2) < 1e-6 {
+			return true
+		}
+	}
+	return false
+}
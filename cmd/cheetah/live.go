@@ -0,0 +1,449 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bballant/modir/pkg/lineup"
+)
+
+func runLive(args []string) {
+	flags := flag.NewFlagSet("cheetah live", flag.ExitOnError)
+	game := addGameFlags(flags)
+	actualFile := flags.String("actual", "actual_schedule.csv", "File to write the schedule as it was played to")
+	logFile := flags.String("log", "actual_minutes.csv", "File to write each player's actual minutes to (.csv or .json)")
+	out := addRenderFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: cheetah live [flags] planned.csv")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	plan, err := game.readScheduleFile(flags.Arg(0))
+	if err != nil {
+		fatal(err)
+	}
+
+	g := newLiveGame(plan, os.Stdout, time.Now)
+	g.run(os.Stdin)
+
+	actual := g.result()
+	f, err := os.Create(*actualFile)
+	if err != nil {
+		fatal(err)
+	}
+	if err := lineup.WriteSchedule(f, actual, actual.Timed); err != nil {
+		fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		fatal(err)
+	}
+
+	playingTime := lineup.CountPlayingTime(actual)
+	if err := writeReport(*logFile, playingTime); err != nil {
+		fatal(err)
+	}
+	if err := out.render(actual, g.roster, playingTime, nil, nil); err != nil {
+		fatal(err)
+	}
+	fmt.Printf("Wrote %s and %s\n", *actualFile, *logFile)
+}
+
+// gameClock is a stopwatch for the game that can be paused at breaks.
+type gameClock struct {
+	running bool
+	since   time.Time
+	elapsed time.Duration
+}
+
+func (c *gameClock) start(now time.Time) {
+	if !c.running {
+		c.running = true
+		c.since = now
+	}
+}
+
+func (c *gameClock) stop(now time.Time) {
+	if c.running {
+		c.elapsed += now.Sub(c.since)
+		c.running = false
+	}
+}
+
+func (c *gameClock) set(minutes float64, now time.Time) {
+	c.elapsed = time.Duration(minutes * float64(time.Minute))
+	c.since = now
+}
+
+// minutes is the time on the clock, to the second.
+func (c *gameClock) minutes(now time.Time) float64 {
+	d := c.elapsed
+	if c.running {
+		d += now.Sub(c.since)
+	}
+	return math.Round(d.Seconds()) / 60
+}
+
+// liveGame follows a planned schedule during a game, recording the lineups
+// that were actually played.
+type liveGame struct {
+	plan      *lineup.Schedule
+	next      int // index of the next planned period
+	announced int // planned periods already announced as due
+	breaks    int // ends of halves or quarters already announced
+	lineup    []string
+	periods   []lineup.Period // as played
+	roster    []string
+	absent    map[string]bool
+	clock     gameClock
+	w         io.Writer
+	now       func() time.Time
+}
+
+func newLiveGame(plan *lineup.Schedule, w io.Writer, now func() time.Time) *liveGame {
+	g := &liveGame{plan: plan, roster: plan.Players(), absent: map[string]bool{}, w: w, now: now}
+
+	g.lineup = append([]string{}, plan.Periods[0].Players...)
+	g.periods = []lineup.Period{{Players: g.lineup, Start: 0}}
+	g.next, g.announced = 1, 1
+	return g
+}
+
+const liveHelp = `Commands:
+  start           start or restart the clock
+  pause           stop the clock, e.g. at a break
+  <enter>, next   make the next planned subs now
+  sub IN OUT      IN comes on for OUT (or they swap if both are on)
+  out NAME        NAME can't play any more; a bench player replaces them
+  back NAME       NAME can play again
+  clock MM:SS     correct the game clock
+  status          show the clock, lineup and minutes so far
+  end             finish the game and write out what happened`
+
+// run reads commands from in until the game ends or in is closed, checking
+// the clock every second for subs that are due.
+func (g *liveGame) run(in io.Reader) {
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	fmt.Fprintln(g.w, liveHelp)
+	g.status()
+	g.prompt()
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			if g.command(line) {
+				return
+			}
+			g.prompt()
+		case <-ticker.C:
+			g.checkClock()
+		}
+	}
+}
+
+func (g *liveGame) prompt() {
+	state := "stopped"
+	if g.clock.running {
+		state = "running"
+	}
+	fmt.Fprintf(g.w, "[%s %s] > ", lineup.FormatClock(g.clock.minutes(g.now())), state)
+}
+
+// command carries out one line of input, returning true when the game is
+// over.
+func (g *liveGame) command(line string) bool {
+	args := strings.Fields(line)
+	if len(args) == 0 {
+		g.applyNext()
+		return false
+	}
+
+	switch args[0] {
+	case "next", "n":
+		g.applyNext()
+	case "start", "go", "resume":
+		g.clock.start(g.now())
+	case "pause", "stop":
+		g.clock.stop(g.now())
+	case "sub":
+		if len(args) != 3 {
+			fmt.Fprintln(g.w, "usage: sub IN OUT")
+			break
+		}
+		g.substitute(args[1], args[2])
+	case "out":
+		if len(args) != 2 {
+			fmt.Fprintln(g.w, "usage: out NAME")
+			break
+		}
+		g.markOut(args[1])
+	case "back":
+		if len(args) != 2 {
+			fmt.Fprintln(g.w, "usage: back NAME")
+			break
+		}
+		delete(g.absent, args[1])
+	case "clock":
+		if len(args) != 2 {
+			fmt.Fprintln(g.w, "usage: clock MM:SS")
+			break
+		}
+		minutes, err := lineup.ParseClock(args[1])
+		if err != nil {
+			fmt.Fprintln(g.w, err)
+			break
+		}
+		// Periods already recorded can't be made to run backwards.
+		if last := g.periods[len(g.periods)-1].Start; minutes < last {
+			fmt.Fprintf(g.w, "the clock can't go back before %s, when the last subs were made\n", lineup.FormatClock(last))
+			break
+		}
+		g.clock.set(minutes, g.now())
+	case "status", "s":
+		g.status()
+	case "help", "?":
+		fmt.Fprintln(g.w, liveHelp)
+	case "end", "quit", "q":
+		return true
+	default:
+		fmt.Fprintf(g.w, "unknown command %q, type help for a list\n", args[0])
+	}
+	return false
+}
+
+// checkClock announces planned subs when they fall due, and the end of each
+// half or quarter.
+func (g *liveGame) checkClock() {
+	t := g.clock.minutes(g.now())
+	if g.announced == g.next && g.next < len(g.plan.Periods) && t >= g.plan.Periods[g.next].Start {
+		g.announced++
+		fmt.Fprintf(g.w, "\a\nSubs due at %s (press enter to make them):\n", lineup.FormatClock(g.plan.Periods[g.next].Start))
+		for _, sub := range describeSubs(g.plan.Formation, g.lineup, g.planned(g.next)) {
+			fmt.Fprintln(g.w, "  "+sub)
+		}
+		g.prompt()
+	}
+	if g.breaks < g.plan.Segments && t >= float64(g.breaks+1)*g.plan.SegmentLength() {
+		g.breaks++
+		if g.breaks == g.plan.Segments {
+			fmt.Fprint(g.w, "\a\nFull time (type end to finish)\n")
+		} else {
+			fmt.Fprintf(g.w, "\a\nEnd of %s (type pause to stop the clock)\n", g.plan.SegmentNames()[g.breaks-1])
+		}
+		g.prompt()
+	}
+}
+
+// planned is the lineup planned for period i, with anyone who can't play
+// replaced from the bench.
+func (g *liveGame) planned(i int) []string {
+	row := append([]string{}, g.plan.Periods[i].Players...)
+	for idx, name := range row {
+		if g.absent[name] {
+			row[idx] = g.replacement(row)
+		}
+	}
+	return row
+}
+
+// replacement picks the available bench player who has played the least
+// so far, or "" if there is nobody left.
+func (g *liveGame) replacement(row []string) string {
+	on := map[string]bool{}
+	for _, name := range row {
+		on[name] = true
+	}
+	var bench []string
+	for _, name := range g.roster {
+		if !on[name] && !g.absent[name] {
+			bench = append(bench, name)
+		}
+	}
+	if len(bench) == 0 {
+		return ""
+	}
+
+	played := lineup.CountPlayingTime(g.soFar())
+	sort.SliceStable(bench, func(i, j int) bool {
+		return played.Total(bench[i]) < played.Total(bench[j])
+	})
+	return bench[0]
+}
+
+func (g *liveGame) applyNext() {
+	if g.next >= len(g.plan.Periods) {
+		fmt.Fprintln(g.w, "No more planned subs")
+		return
+	}
+	g.change(g.planned(g.next))
+	g.next++
+	if g.announced < g.next {
+		g.announced = g.next
+	}
+}
+
+func (g *liveGame) substitute(in, out string) {
+	row := append([]string{}, g.lineup...)
+	outIdx, inIdx := -1, -1
+	for idx, name := range row {
+		if name == out {
+			outIdx = idx
+		}
+		if name == in {
+			inIdx = idx
+		}
+	}
+	if outIdx < 0 {
+		fmt.Fprintf(g.w, "%s isn't on the field\n", out)
+		return
+	}
+
+	if inIdx >= 0 {
+		row[inIdx] = out
+	} else if !g.inRoster(in) {
+		g.roster = append(g.roster, in)
+	}
+	row[outIdx] = in
+	g.change(row)
+}
+
+func (g *liveGame) markOut(name string) {
+	if !g.inRoster(name) {
+		fmt.Fprintf(g.w, "%s isn't on the team\n", name)
+		return
+	}
+	g.absent[name] = true
+
+	row := append([]string{}, g.lineup...)
+	for idx, n := range row {
+		if n == name {
+			row[idx] = g.replacement(row)
+			if row[idx] == "" {
+				fmt.Fprintln(g.w, "Nobody left on the bench, playing a player down")
+			}
+			g.change(row)
+			return
+		}
+	}
+}
+
+func (g *liveGame) inRoster(name string) bool {
+	for _, n := range g.roster {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// change records the lineup row as being on the field from now on.
+func (g *liveGame) change(row []string) {
+	t := g.clock.minutes(g.now())
+	for _, sub := range describeSubs(g.plan.Formation, g.lineup, row) {
+		fmt.Fprintf(g.w, "%s %s\n", lineup.FormatClock(t), sub)
+	}
+
+	last := &g.periods[len(g.periods)-1]
+	if last.Start == t {
+		last.Players = row
+	} else {
+		g.periods = append(g.periods, lineup.Period{Players: row, Start: t})
+	}
+	g.lineup = row
+}
+
+// soFar is the schedule as played up to now.
+func (g *liveGame) soFar() *lineup.Schedule {
+	s := &lineup.Schedule{
+		Formation: g.plan.Formation,
+		Periods:   append([]lineup.Period{}, g.periods...),
+		GameTime:  g.clock.minutes(g.now()),
+		Segments:  1,
+		Timed:     true,
+	}
+	for i := range s.Periods {
+		if i+1 < len(s.Periods) {
+			s.Periods[i].End = s.Periods[i+1].Start
+		} else {
+			s.Periods[i].End = s.GameTime
+		}
+	}
+	return s
+}
+
+func (g *liveGame) status() {
+	t := g.clock.minutes(g.now())
+	fmt.Fprintf(g.w, "Clock %s", lineup.FormatClock(t))
+	if g.next < len(g.plan.Periods) {
+		fmt.Fprintf(g.w, ", next subs planned at %s", lineup.FormatClock(g.plan.Periods[g.next].Start))
+	}
+	fmt.Fprintln(g.w)
+
+	on := map[string]bool{}
+	for idx, name := range g.lineup {
+		on[name] = true
+		fmt.Fprintf(g.w, "  %-4s %s\n", g.plan.Formation.Slots[idx].Symbol, name)
+	}
+
+	played := lineup.CountPlayingTime(g.soFar())
+	fmt.Fprint(g.w, "Minutes:")
+	for _, name := range g.roster {
+		mark := ""
+		switch {
+		case g.absent[name]:
+			mark = " (out)"
+		case !on[name]:
+			mark = " (bench)"
+		}
+		fmt.Fprintf(g.w, " %s %s%s", name, lineup.FormatClock(played.Total(name)), mark)
+	}
+	fmt.Fprintln(g.w)
+}
+
+// result is the schedule as played, ending at the current time on the
+// clock.
+func (g *liveGame) result() *lineup.Schedule {
+	s := g.soFar()
+	// The halves or quarters are as long as planned, however long the game
+	// actually ran.
+	if s.Segments = g.plan.Segments; s.Segments > 1 {
+		s.SegmentTime = g.plan.SegmentLength()
+	}
+
+	// A change made at the final whistle never got played.
+	if n := len(s.Periods); n > 1 && s.Periods[n-1].Minutes() <= 0 {
+		s.Periods = s.Periods[:n-1]
+		s.Periods[n-2].End = s.GameTime
+	}
+	if s.GameTime <= 0 {
+		// The clock was never started; keep the planned length so the
+		// schedule can still be drawn.
+		s.GameTime = g.plan.GameTime
+		s.Periods[len(s.Periods)-1].End = s.GameTime
+	}
+	return s
+}
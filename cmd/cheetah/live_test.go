@@ -0,0 +1,56 @@
+package main
+
+import (
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/bballant/modir/pkg/lineup"
+)
+
+func TestLiveResultKeepsPlannedHalves(t *testing.T) {
+	formations, err := lineup.LoadFormations("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	formation, err := lineup.FindFormation(formations, "331")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := lineup.EvenSchedule(formation, [][]string{
+		{"Puma", "Tiger", "Cougar", "Leopard", "Jaguar", "Lynx", "Snowleopard", "Cheetah"},
+		{"Puma", "Tiger", "Cougar", "Leopard", "Jaguar", "Lynx", "Snowleopard", "Lion"},
+	}, 52)
+	plan.Segments = 2
+
+	// The subs are made at 13:00 and the game is called at 50:00, two
+	// minutes short of the 52 planned.
+	now := time.Date(2026, 10, 4, 9, 0, 0, 0, time.UTC)
+	g := newLiveGame(plan, io.Discard, func() time.Time { return now })
+	g.command("start")
+	now = now.Add(13 * time.Minute)
+	g.command("next")
+	now = now.Add(37 * time.Minute)
+
+	s := g.result()
+	if s.GameTime != 50 {
+		t.Fatalf("GameTime = %v, want 50", s.GameTime)
+	}
+	pt := lineup.CountPlayingTime(s)
+	tests := []struct {
+		name   string
+		halves []float64
+	}{
+		{"Puma", []float64{26, 24}},
+		{"Cheetah", []float64{13, 0}},
+		{"Lion", []float64{13, 24}},
+	}
+	for _, tt := range tests {
+		for half, want := range tt.halves {
+			if got := pt.InSegment(tt.name, half); math.Abs(got-want) > 1e-9 {
+				t.Errorf("%s played %v in half %d, want %v", tt.name, got, half+1, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"math"
+	"os"
+)
+
+// Paper sizes in points.
+var paperSizes = map[string][2]float64{
+	"letter": {612, 792},
+	"a4":     {595, 842},
+}
+
+const pdfMargin = 36
+
+// pdfCanvas draws pages as vector graphics and writes them all to a single
+// PDF document. Every page is drawn at the same scale, as large as fits on
+// the paper, turned to landscape if that fits better.
+type pdfCanvas struct {
+	name  string
+	paper [2]float64
+	pages []*pdfPage
+	page  *pdfPage
+}
+
+type pdfPage struct {
+	width, height int
+	content       bytes.Buffer
+}
+
+func newPDFCanvas(name, paper string) (*pdfCanvas, error) {
+	size, ok := paperSizes[paper]
+	if !ok {
+		return nil, fmt.Errorf("unknown paper size %q (want letter or a4)", paper)
+	}
+	return &pdfCanvas{name: name, paper: size}, nil
+}
+
+func (c *pdfCanvas) NewPage(width, height int) {
+	c.page = &pdfPage{width: width, height: height}
+	c.pages = append(c.pages, c.page)
+}
+
+func (c *pdfCanvas) Line(col color.Color, thickness, x1, y1, x2, y2 int) {
+	// The PNG backend thickens lines down and to the right of the points.
+	o := float64(thickness-1) / 2
+	fmt.Fprintf(&c.page.content, "%s RG %d w %.2f %.2f m %.2f %.2f l S\n",
+		pdfColor(col), thickness, float64(x1)+o, float64(y1)+o, float64(x2)+o, float64(y2)+o)
+}
+
+func (c *pdfCanvas) Circle(col color.Color, x, y, r int, filled bool) {
+	// Four Bézier curves, one per quadrant.
+	cx, cy, rr := float64(x), float64(y), float64(r)
+	k := 0.5523 * rr
+	b := &c.page.content
+	if filled {
+		fmt.Fprintf(b, "%s rg\n", pdfColor(col))
+	} else {
+		fmt.Fprintf(b, "%s RG 1 w\n", pdfColor(col))
+	}
+	fmt.Fprintf(b, "%.2f %.2f m\n", cx+rr, cy)
+	fmt.Fprintf(b, "%.2f %.2f %.2f %.2f %.2f %.2f c\n", cx+rr, cy+k, cx+k, cy+rr, cx, cy+rr)
+	fmt.Fprintf(b, "%.2f %.2f %.2f %.2f %.2f %.2f c\n", cx-k, cy+rr, cx-rr, cy+k, cx-rr, cy)
+	fmt.Fprintf(b, "%.2f %.2f %.2f %.2f %.2f %.2f c\n", cx-rr, cy-k, cx-k, cy-rr, cx, cy-rr)
+	fmt.Fprintf(b, "%.2f %.2f %.2f %.2f %.2f %.2f c\n", cx+k, cy-rr, cx+rr, cy-k, cx+rr, cy)
+	if filled {
+		b.WriteString("f\n")
+	} else {
+		b.WriteString("S\n")
+	}
+}
+
+func (c *pdfCanvas) Arc(col color.Color, x, y, r int, start, end float64) {
+	// A line every few degrees is smooth enough at the sizes drawn.
+	b := &c.page.content
+	fmt.Fprintf(b, "%s RG 1 w\n", pdfColor(col))
+	steps := int(math.Ceil((end - start) / 5))
+	for i := 0; i <= steps; i++ {
+		a := (start + (end-start)*float64(i)/float64(steps)) * math.Pi / 180
+		op := "l"
+		if i == 0 {
+			op = "m"
+		}
+		fmt.Fprintf(b, "%.2f %.2f %s\n", float64(x)+float64(r)*math.Cos(a), float64(y)+float64(r)*math.Sin(a), op)
+	}
+	b.WriteString("S\n")
+}
+
+func (c *pdfCanvas) Rect(col color.Color, x, y, width, height int) {
+	fmt.Fprintf(&c.page.content, "%s rg %d %d %d %d re f\n", pdfColor(col), x, y, width, height)
+}
+
+func (c *pdfCanvas) Text(size float64, text string, x, y int) {
+	// The page is drawn upside down (see Close), so flip the text back.
+	fmt.Fprintf(&c.page.content, "0 g BT /F1 %.1f Tf 1 0 0 -1 %d %d Tm (%s) Tj ET\n",
+		size, x, y, pdfString(text))
+}
+
+func (c *pdfCanvas) Close() error {
+	paperW, paperH := c.paper[0], c.paper[1]
+	if c.scale(paperH, paperW) > c.scale(paperW, paperH) {
+		paperW, paperH = paperH, paperW
+	}
+	scale := c.scale(paperW, paperH)
+
+	var out bytes.Buffer
+	var offsets []int
+	object := func(format string, args ...interface{}) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n", len(offsets))
+		fmt.Fprintf(&out, format, args...)
+		out.WriteString("\nendobj\n")
+	}
+
+	// Objects 1 to 3 are the catalog, page tree and font; each page is
+	// then a page object followed by its content stream.
+	kids := ""
+	for i := range c.pages {
+		kids += fmt.Sprintf("%d 0 R ", 4+2*i)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object("<< /Type /Pages /Kids [%s] /Count %d >>", kids, len(c.pages))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, p := range c.pages {
+		// Center the page horizontally, at the top of the paper, with y
+		// running down the page as it does in the PNG output.
+		tx := (paperW - float64(p.width)*scale) / 2
+		ty := paperH - pdfMargin
+		content := fmt.Sprintf("q %.4f 0 0 %.4f %.2f %.2f cm 1 J\n%sQ\n", scale, -scale, tx, ty, p.content.String())
+
+		object("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			paperW, paperH, 5+2*i)
+		object("<< /Length %d >>\nstream\n%sendstream", len(content), content)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return os.WriteFile(c.name, out.Bytes(), 0644)
+}
+
+// scale is the largest scale at which every page fits inside the margins of
+// paper of the given size.
+func (c *pdfCanvas) scale(paperW, paperH float64) float64 {
+	scale := math.Inf(1)
+	for _, p := range c.pages {
+		scale = math.Min(scale, (paperW-2*pdfMargin)/float64(p.width))
+		scale = math.Min(scale, (paperH-2*pdfMargin)/float64(p.height))
+	}
+	return scale
+}
+
+func pdfColor(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("%.3f %.3f %.3f", float64(r)/0xffff, float64(g)/0xffff, float64(b)/0xffff)
+}
+
+// pdfString escapes text for a PDF string literal. Characters outside
+// Latin-1 can't be shown in the standard fonts and become question marks.
+func pdfString(text string) string {
+	var b bytes.Buffer
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r < 0x20 || r > 0xff:
+			b.WriteByte('?')
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+	return b.String()
+}
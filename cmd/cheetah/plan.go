@@ -0,0 +1,502 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+
+	"github.com/bballant/modir/pkg/lineup"
+)
+
+func runPlan(args []string) {
+	flags := flag.NewFlagSet("cheetah plan", flag.ExitOnError)
+	rosterFile := flags.String("roster", "", "File with one player name per line")
+	game := addGameFlags(flags)
+	keeper := addKeeperFlags(flags)
+	strength := addStrengthFlags(flags)
+	periods := flags.Int("p", 8, "Number of periods to split the game into")
+	seed := flags.Int64("seed", 1, "Seed for choosing between equally fair rotations")
+	output := flags.String("o", "", "File to write the schedule CSV to (default stdout)")
+	flags.Parse(args)
+
+	if *rosterFile == "" {
+		fatal(fmt.Errorf("plan: -roster is required"))
+	}
+	roster, err := readRoster(*rosterFile)
+	if err != nil {
+		fatal(err)
+	}
+	formation, err := game.formation()
+	if err != nil {
+		fatal(err)
+	}
+	// Subs are planned to happen at the breaks between halves or quarters,
+	// so each one has to be a whole number of periods.
+	segments, err := game.segments()
+	if err != nil {
+		fatal(err)
+	}
+	gameTime, err := game.length()
+	if err != nil {
+		fatal(err)
+	}
+	if *periods%segments != 0 {
+		fatal(fmt.Errorf("plan: %d periods can't be split evenly between %d halves or quarters", *periods, segments))
+	}
+	if *keeper.fullHalf && *periods%2 != 0 {
+		fatal(fmt.Errorf("plan: -keeper-half needs an even number of periods, so subs happen at half time"))
+	}
+
+	rules, err := keeper.rules(formation, roster)
+	if err != nil {
+		fatal(err)
+	}
+
+	balance := strength.rules(roster)
+
+	rows, err := planSchedule(playerNames(roster), formation, *periods, rules, balance, rand.New(rand.NewSource(*seed)))
+	if err != nil {
+		fatal(err)
+	}
+	schedule := lineup.EvenSchedule(formation, rows, gameTime)
+	schedule.Segments = segments
+	warn(schedule, append(rules.check(schedule), balance.check(schedule)...))
+
+	w := os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fatal(err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := lineup.WriteSchedule(w, schedule, false); err != nil {
+		fatal(err)
+	}
+
+	// Report the planned minutes on stderr so stdout can be piped straight
+	// into the renderer.
+	playingTime := lineup.CountPlayingTime(schedule)
+	for _, p := range roster {
+		fmt.Fprintf(os.Stderr, "%-12s %s\n", p.name, lineup.FormatClock(playingTime.Total(p.name)))
+	}
+}
+
+// planSchedule builds a rotation of the roster through the formation's slots
+// for the given number of periods. Players who have played the least go on
+// first, so total playing time differs by at most one period, and each player
+// is moved into the slots they have played the least. A keeper kept in goal
+// for a whole half is whoever has played least, and anyone left more than a
+// period ahead is then evened out. When players have skill ratings, the
+// lineups are balanced to keep each period's strength within the band,
+// trying other rotations if that can't be done.
+func planSchedule(roster []string, formation lineup.Formation, periods int, rules *keeperRules, strength *strengthRules, rng *rand.Rand) ([][]string, error) {
+	if periods < 1 {
+		return nil, fmt.Errorf("plan: need at least one period")
+	}
+	if len(roster) < len(formation.Slots) {
+		return nil, fmt.Errorf("plan: formation %s needs %d players but the roster has %d",
+			formation.Name, len(formation.Slots), len(roster))
+	}
+
+	for attempt := 0; attempt < planAttempts; attempt++ {
+		// Plan in periods rather than minutes, so ties between players are
+		// exact.
+		p := newPlanner(roster, formation, rules, float64(periods)/2, rng)
+		var rows [][]string
+		for i := 0; i < periods; i++ {
+			rows = append(rows, p.next(float64(i), float64(i+1)))
+		}
+		levelPlayingTime(rows, roster, p.rules.slot)
+		s, ok := balanceSchedule(lineup.EvenSchedule(formation, rows, float64(periods)), 0, p.rules, strength)
+		if ok {
+			for i, period := range s.Periods {
+				rows[i] = period.Players
+			}
+			return rows, nil
+		}
+	}
+	return nil, fmt.Errorf("plan: can't keep every period's team strength within %.0f%% of the game's average; try a wider -strength-band",
+		strength.band*100)
+}
+
+// levelPlayingTime evens out rows where a keeper kept on for a whole half
+// has left someone more than one period ahead of someone else. A player
+// who has played most is replaced by one who has played least, in a period
+// the second sits out, never in goal.
+func levelPlayingTime(rows [][]string, roster []string, goal int) {
+	for {
+		played := map[string]int{}
+		for _, row := range rows {
+			for _, name := range row {
+				played[name]++
+			}
+		}
+		most, least := played[roster[0]], played[roster[0]]
+		for _, name := range roster {
+			if played[name] > most {
+				most = played[name]
+			}
+			if played[name] < least {
+				least = played[name]
+			}
+		}
+		if most-least <= 1 || !moveOnce(rows, roster, goal, played, most, least) {
+			return
+		}
+	}
+}
+
+// moveOnce puts a player who has played least periods in place of one who
+// has played most, in the first period where that is possible, and reports
+// whether it found one.
+func moveOnce(rows [][]string, roster []string, goal int, played map[string]int, most, least int) bool {
+	for _, row := range rows {
+		for slot, name := range row {
+			if slot == goal || played[name] != most {
+				continue
+			}
+			for _, sub := range roster {
+				if played[sub] == least && !contains(row, sub) {
+					row[slot] = sub
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// planAttempts is how many rotations plan and replan try before giving up on
+// keeping every period's team strength within the band.
+const planAttempts = 50
+
+// balanceSchedule changes the lineups of s from period index from onwards
+// until each one's team strength is within the band around the game's
+// average, as check measures them. It only swaps two players' positions in
+// a period, or trades two players between periods of the same length, so
+// nobody's minutes change, and it makes no change that breaks more
+// goalkeeper rules. It reports whether every period from from onwards ends
+// up in the band.
+func balanceSchedule(s *lineup.Schedule, from int, rules *keeperRules, strength *strengthRules) (*lineup.Schedule, bool) {
+	if !strength.rated() {
+		return s, true
+	}
+	keeperProblems := len(rules.check(s))
+	for {
+		excess := strengthExcess(s, from, strength)
+		if excess == 0 {
+			return s, true
+		}
+		best, bestExcess := s, excess
+		for _, t := range balanceMoves(s, from) {
+			if e := strengthExcess(t, from, strength); e < bestExcess-1e-9 && len(rules.check(t)) <= keeperProblems {
+				best, bestExcess = t, e
+			}
+		}
+		if best == s {
+			return s, false
+		}
+		s = best
+	}
+}
+
+// strengthExcess adds up how far outside the strength band the periods of s
+// from index from onwards are.
+func strengthExcess(s *lineup.Schedule, from int, strength *strengthRules) float64 {
+	strengths, average := strength.periods(s)
+	excess := 0.0
+	for _, st := range strengths[from:] {
+		if !strength.inBand(st, average) {
+			excess += math.Abs(st-average) - strength.band*average
+		}
+	}
+	return excess
+}
+
+// balanceMoves lists the schedules one change away from s that leave every
+// player's minutes as they are, changing only periods from index from on.
+func balanceMoves(s *lineup.Schedule, from int) []*lineup.Schedule {
+	var moves []*lineup.Schedule
+	slots := len(s.Formation.Slots)
+	for i := from; i < len(s.Periods); i++ {
+		// Swap two positions, which moves the players to other lines.
+		for a := 0; a < slots; a++ {
+			for b := a + 1; b < slots; b++ {
+				m := copySchedule(s)
+				swapSlots(&m.Periods[i], a, b)
+				moves = append(moves, m)
+			}
+		}
+
+		// Trade a player for one who is on in a later period instead,
+		// each taking the other's place.
+		for j := i + 1; j < len(s.Periods); j++ {
+			if math.Abs(s.Periods[i].Minutes()-s.Periods[j].Minutes()) > 1e-6 {
+				continue
+			}
+			for a, x := range s.Periods[i].Players {
+				if x == "" || contains(s.Periods[j].Players, x) {
+					continue
+				}
+				for b, y := range s.Periods[j].Players {
+					if y == "" || contains(s.Periods[i].Players, y) {
+						continue
+					}
+					m := copySchedule(s)
+					m.Periods[i].Players[a], m.Periods[j].Players[b] = y, x
+					moves = append(moves, m)
+				}
+			}
+		}
+	}
+	return moves
+}
+
+// planner builds lineups one period at a time, keeping track of how long
+// each player has played overall and in each slot.
+type planner struct {
+	roster    []string
+	formation lineup.Formation
+	rules     *keeperRules
+	halfTime  float64
+	played    map[string]float64
+	inSlot    map[string][]float64
+	last      []string
+	rng       *rand.Rand
+}
+
+func newPlanner(roster []string, formation lineup.Formation, rules *keeperRules, halfTime float64, rng *rand.Rand) *planner {
+	if rules == nil {
+		rules = &keeperRules{slot: -1}
+	}
+	return &planner{
+		roster:    roster,
+		formation: formation,
+		rules:     rules,
+		halfTime:  halfTime,
+		played:    map[string]float64{},
+		inSlot:    map[string][]float64{},
+		rng:       rng,
+	}
+}
+
+// record notes that row was on the field for the given number of minutes.
+func (p *planner) record(row []string, minutes float64) {
+	for i, name := range row {
+		if name == "" {
+			continue
+		}
+		if p.inSlot[name] == nil {
+			p.inSlot[name] = make([]float64, len(p.formation.Slots))
+		}
+		p.played[name] += minutes
+		p.inSlot[name][i] += minutes
+	}
+	p.last = row
+}
+
+// next chooses the lineup for the period from start to end and records it.
+func (p *planner) next(start, end float64) []string {
+	n := len(p.formation.Slots)
+	onField := pickPlayers(p.roster, p.played, p.last, n, p.rng)
+
+	// A keeper who has to finish the half stays on, and otherwise someone
+	// who is allowed in goal has to be on the field.
+	keeper := ""
+	if goal := p.rules.slot; goal >= 0 {
+		if len(p.last) > 0 && !p.rules.changesAt(start, p.halfTime) && p.isOnRoster(p.last[goal]) {
+			keeper = p.last[goal]
+			if !contains(onField, keeper) {
+				onField[n-1] = keeper
+			}
+		} else if p.rules.fullHalf {
+			// The keeper is on for the whole half ahead, so it goes to
+			// whoever has played least, to keep their minutes level.
+			if keeper = p.halfKeeper(); keeper != "" && !contains(onField, keeper) {
+				onField[n-1] = keeper
+			}
+		} else if !p.anyKeeper(onField) {
+			if sub := p.benchKeeper(onField); sub != "" {
+				onField[n-1] = sub
+			}
+		}
+	}
+
+	for _, name := range onField {
+		if p.inSlot[name] == nil {
+			p.inSlot[name] = make([]float64, n)
+		}
+	}
+	if keeper == "" && p.rules.slot >= 0 && !p.allKeepers(onField) {
+		// Choose the keeper first, so nobody is left with only the goal to
+		// go in.
+		keeper = p.pickKeeper(onField)
+	}
+	row := assignSlots(onField, func(name string, slot int) float64 {
+		if slot == p.rules.slot {
+			switch {
+			case name == keeper:
+				return math.Inf(-1)
+			case keeper != "" || !p.rules.canKeep(name):
+				return math.Inf(1)
+			}
+		} else if name == keeper {
+			return math.Inf(1)
+		}
+		return p.inSlot[name][slot]
+	}, p.rng)
+	p.record(row, end-start)
+	return row
+}
+
+func (p *planner) isOnRoster(name string) bool {
+	return contains(p.roster, name)
+}
+
+// anyKeeper reports whether any of players may play in goal.
+func (p *planner) anyKeeper(players []string) bool {
+	for _, name := range players {
+		if p.rules.canKeep(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// allKeepers reports whether all of players may play in goal.
+func (p *planner) allKeepers(players []string) bool {
+	for _, name := range players {
+		if !p.rules.canKeep(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// pickKeeper picks the player in onField who may play in goal and has spent
+// the least time there, or "" if there is nobody.
+func (p *planner) pickKeeper(onField []string) string {
+	best := ""
+	for _, i := range p.rng.Perm(len(onField)) {
+		name := onField[i]
+		if !p.rules.canKeep(name) {
+			continue
+		}
+		if best == "" || p.inSlot[name][p.rules.slot] < p.inSlot[best][p.rules.slot] {
+			best = name
+		}
+	}
+	return best
+}
+
+// halfKeeper picks the player who may play in goal and has played the
+// least, then spent the least time in goal, or "" if there is nobody. They
+// stay in goal for the whole half, so they are the one who can best afford
+// the time.
+func (p *planner) halfKeeper() string {
+	best := ""
+	for _, i := range p.rng.Perm(len(p.roster)) {
+		name := p.roster[i]
+		if !p.rules.canKeep(name) {
+			continue
+		}
+		if best == "" || p.played[name] < p.played[best] ||
+			p.played[name] == p.played[best] && p.keeperTime(name) < p.keeperTime(best) {
+			best = name
+		}
+	}
+	return best
+}
+
+// keeperTime is how long name has played in goal.
+func (p *planner) keeperTime(name string) float64 {
+	if p.inSlot[name] == nil {
+		return 0
+	}
+	return p.inSlot[name][p.rules.slot]
+}
+
+// benchKeeper picks the player not in onField who may play in goal and has
+// played the least, or "" if there is nobody.
+func (p *planner) benchKeeper(onField []string) string {
+	best := ""
+	for _, name := range p.roster {
+		if contains(onField, name) || !p.rules.canKeep(name) {
+			continue
+		}
+		if best == "" || p.played[name] < p.played[best] {
+			best = name
+		}
+	}
+	return best
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// pickPlayers chooses n players for the next period, preferring those who
+// have played the least and then those who sat out the last period.
+func pickPlayers(roster []string, played map[string]float64, last []string, n int, rng *rand.Rand) []string {
+	wasOn := map[string]bool{}
+	for _, name := range last {
+		wasOn[name] = true
+	}
+
+	candidates := make([]string, len(roster))
+	copy(candidates, roster)
+	rng.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if played[a] != played[b] {
+			return played[a] < played[b]
+		}
+		return !wasOn[a] && wasOn[b]
+	})
+
+	return candidates[:n]
+}
+
+// assignSlots places players into slots, repeatedly taking the player and
+// open slot pair with the lowest cost, usually the time the player has spent
+// in the slot so far.
+func assignSlots(players []string, cost func(name string, slot int) float64, rng *rand.Rand) []string {
+	row := make([]string, len(players))
+	placed := make([]bool, len(players))
+	playerOrder := rng.Perm(len(players))
+	slotOrder := rng.Perm(len(row))
+
+	for n := 0; n < len(row); n++ {
+		bestPlayer, bestSlot, bestCost := -1, -1, 0.0
+		for _, p := range playerOrder {
+			if placed[p] {
+				continue
+			}
+			for _, s := range slotOrder {
+				if row[s] != "" {
+					continue
+				}
+				c := cost(players[p], s)
+				if bestPlayer < 0 || c < bestCost {
+					bestPlayer, bestSlot, bestCost = p, s, c
+				}
+			}
+		}
+		row[bestSlot] = players[bestPlayer]
+		placed[bestPlayer] = true
+	}
+
+	return row
+}
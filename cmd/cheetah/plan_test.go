@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/bballant/modir/pkg/lineup"
+)
+
+func TestPlanScheduleSpread(t *testing.T) {
+	formations, err := lineup.LoadFormations("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	formation, err := lineup.FindFormation(formations, "331")
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"Puma", "Tiger", "Cougar", "Leopard", "Jaguar", "Lynx", "Snowleopard",
+		"Cheetah", "Lion", "Bobcat", "Ocelot", "Serval", "Caracal", "Margay"}
+
+	tests := []struct {
+		players, periods int
+		fullHalf         bool
+	}{
+		{12, 8, false},
+		{12, 8, true},
+		{11, 6, true},
+		{13, 4, true},
+		{14, 10, true},
+		{9, 8, true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d players %d periods keeper-half %v", tt.players, tt.periods, tt.fullHalf), func(t *testing.T) {
+			for seed := int64(1); seed <= 20; seed++ {
+				rules := &keeperRules{slot: 0, banned: map[string]string{}, fullHalf: tt.fullHalf}
+				rows, err := planSchedule(names[:tt.players], formation, tt.periods, rules, nil, rand.New(rand.NewSource(seed)))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				played := map[string]int{}
+				for _, row := range rows {
+					for _, name := range row {
+						played[name]++
+					}
+				}
+				least, most := tt.periods, 0
+				for _, name := range names[:tt.players] {
+					if played[name] < least {
+						least = played[name]
+					}
+					if played[name] > most {
+						most = played[name]
+					}
+				}
+				if most-least > 1 {
+					t.Errorf("seed %d: periods played range from %d to %d, want at most one apart", seed, least, most)
+				}
+
+				s := lineup.EvenSchedule(formation, rows, float64(tt.periods))
+				if problems := rules.check(s); len(problems) > 0 {
+					t.Errorf("seed %d: %s", seed, problems[0].text)
+				}
+			}
+		})
+	}
+}
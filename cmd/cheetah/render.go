@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/bballant/modir/pkg/lineup"
+)
+
+// renderSchedule draws a field diagram for every period in the schedule,
+// imagesPerCol fields high and cols fields wide on each page. The playing
+// time summary goes at the bottom of the last page. Beside each field is
+// who from roster is on the bench and the team's strength, if strengths are
+// given, and problems are marked on the fields of the periods they are in.
+func renderSchedule(c lineup.Canvas, schedule *lineup.Schedule, field Field, roster []string, playingTime *lineup.PlayingTime, problems []problem, strengths []float64, imagesPerCol, cols int) {
+	width, height := 400, 300
+	lineColor := color.Black
+	playerColor := color.Gray{Y: 128}
+	lineThickness := 3
+	problemColor := color.RGBA{R: 220, A: 255}
+	changesTextOffsetX := 300
+	summaryTextOffsetY := 50
+	benchWidth := 34 // characters of 14pt text that fit beside a field
+	highlightColor := color.RGBA{R: 255, G: 230, B: 100, A: 255}
+
+	formation := schedule.Formation
+	periods := len(schedule.Periods)
+	perPage := imagesPerCol * cols
+	pages := (periods + perPage - 1) / perPage
+
+	imgWidth := width*cols + changesTextOffsetX*cols
+	tableTop := height*imagesPerCol + summaryTextOffsetY
+
+	var streaks []string
+	for _, s := range benchStreaks(schedule, roster) {
+		streaks = append(streaks, s.String())
+	}
+	if len(streaks) > 0 {
+		streaks = wrapWords("Benched for periods in a row: ", streaks, ", ", 100)
+	}
+
+	for page := 0; page < pages; page++ {
+		lastPage := page == pages-1
+
+		imgHeight := height * imagesPerCol
+		if lastPage {
+			imgHeight = tableTop + (len(playingTime.Players())+3+len(streaks))*18
+		}
+		c.NewPage(imgWidth, imgHeight)
+
+		for n := 0; n < perPage && page*perPage+n < periods; n++ {
+			i := page*perPage + n + 1 // period number, counting across pages
+			row := schedule.Periods[i-1].Players
+
+			colIndex := n / imagesPerCol
+			rowIndex := n % imagesPerCol
+
+			offsetX := colIndex * (width + changesTextOffsetX)
+			offsetY := rowIndex * height
+
+			// Draw the field to scale, with its markings
+			fieldX, fieldY, fieldW, fieldH := fitField(field, offsetX, offsetY, width, height)
+			drawField(c, field, fieldX, fieldY, fieldW, fieldH, lineColor, lineThickness)
+
+			playerRadius := 10
+			playerPositions := lineup.Positions(fieldX, fieldY, fieldW, fieldH, formation)
+
+			playerNames := make([]string, len(row))
+			copy(playerNames, row)
+
+			drawPlayers(c, playerColor, playerRadius, playerPositions, playerNames)
+
+			addLabel(c, strconv.Itoa(i), offsetX+10, offsetY+height-10)
+
+			if schedule.StartsSegment(i - 1) {
+				addLabel(c, segmentTitle(schedule, i-1), offsetX+10, offsetY+20)
+			}
+
+			drawChanges(c, offsetX+width+10, offsetY+height-10, []string{schedule.Clock(i - 1)})
+
+			var labels []string
+			for _, p := range problems {
+				if p.period != i-1 {
+					continue
+				}
+				if p.slot >= 0 {
+					pos := playerPositions[p.slot]
+					c.Circle(problemColor, pos.X, pos.Y, playerRadius+4, false)
+					c.Circle(problemColor, pos.X, pos.Y, playerRadius+5, false)
+				}
+				labels = append(labels, "! "+p.label)
+			}
+			sitting := bench(roster, row)
+			if len(sitting) > 0 {
+				labels = append(wrapWords("Bench: ", sitting, ", ", benchWidth), labels...)
+			}
+			if strengths != nil {
+				labels = append([]string{fmt.Sprintf("Strength %.1f", strengths[i-1])}, labels...)
+			}
+			for n, label := range labels {
+				addLabel(c, label, offsetX+width+10, offsetY+height-10-18*(len(labels)-n))
+			}
+
+			var subs []string
+
+			if i == 1 && periods > 1 {
+				nextRow := schedule.Periods[1].Players
+				for idx, name := range row {
+					if nextRow[idx] != name {
+						subs = append(subs, nextRow[idx])
+					}
+				}
+			} else if i > 1 {
+				subs = describeSubs(formation, schedule.Periods[i-2].Players, row)
+			}
+
+			if len(subs) > 0 {
+				drawChanges(c, offsetX+width+10, offsetY+20, subs)
+			}
+		}
+
+		if lastPage {
+			summary := ""
+			overflow := ""
+			for _, name := range playingTime.Players() {
+				if len(summary) < 100 {
+					summary = fmt.Sprintf("%s %s %s", summary, name, lineup.FormatClock(playingTime.Total(name)))
+				} else {
+					overflow = fmt.Sprintf("%s %s %s", overflow, name, lineup.FormatClock(playingTime.Total(name)))
+				}
+
+			}
+			drawChanges(c, 5, tableTop-30, []string{summary})
+			if overflow != "" {
+				drawChanges(c, 5, tableTop-10, []string{overflow})
+			}
+
+			// Minutes per position line
+			table := playingTime.Table()
+			drawChanges(c, 5, tableTop+30, table)
+
+			// Highlight anyone left on the bench for more than one period
+			streaksTop := tableTop + 38 + len(table)*18
+			for n, line := range streaks {
+				y := streaksTop + n*18
+				indent := len(line) - len(strings.TrimLeft(line, " "))
+				c.Rect(highlightColor, 3+indent*11, y-15, (len(line)-indent)*11+4, 19)
+				drawChanges(c, 5, y, []string{line})
+			}
+		}
+	}
+}
+
+// describeSubs lists the changes from the prev lineup to row, one line per
+// slot that changed, like "LB Lion for Tiger".
+func describeSubs(formation lineup.Formation, prev, row []string) []string {
+	changes := lineup.Changes(prev, row)
+	maxNameLen := 0
+	for _, ch := range changes {
+		if len(ch.In) > maxNameLen {
+			maxNameLen = len(ch.In)
+		}
+	}
+
+	var subs []string
+	for _, ch := range changes {
+		pos := formation.Slots[ch.Slot].Symbol
+		subs = append(subs, fmt.Sprintf("%s %-*s for %s", pos, maxNameLen, ch.In, ch.Out))
+	}
+	return subs
+}
+
+// segmentTitle names the half or quarter that period i starts, like
+// "2nd half" or "Quarter 3".
+func segmentTitle(s *lineup.Schedule, i int) string {
+	seg := s.SegmentAt(s.Periods[i].Start)
+	if s.Segments == 2 {
+		return [...]string{"1st half", "2nd half"}[seg]
+	}
+	return fmt.Sprintf("Quarter %d", seg+1)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+
+	"github.com/bballant/modir/pkg/lineup"
+)
+
+func runReplan(args []string) {
+	flags := flag.NewFlagSet("cheetah replan", flag.ExitOnError)
+	absentList := flags.String("absent", "", "Comma-separated players who can't play, e.g. Lynx,Puma")
+	from := flags.Int("from", 1, "First period to re-plan; earlier periods are kept as they are")
+	rosterFile := flags.String("roster", "", "File with one player name per line, for players not already in the schedule")
+	game := addGameFlags(flags)
+	keeper := addKeeperFlags(flags)
+	strength := addStrengthFlags(flags)
+	seed := flags.Int64("seed", 1, "Seed for choosing between equally fair rotations")
+	output := flags.String("o", "", "File to write the revised schedule CSV to (default stdout)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: cheetah replan -absent NAME[,NAME...] [flags] schedule.csv")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	schedule, err := game.readScheduleFile(flags.Arg(0))
+	if err != nil {
+		fatal(err)
+	}
+
+	roster := schedule.Players()
+	var players []Player
+	if *rosterFile != "" {
+		players, err = readRoster(*rosterFile)
+		if err != nil {
+			fatal(err)
+		}
+		roster = lineup.MergeNames(roster, playerNames(players))
+	}
+	rules, err := keeper.rules(schedule.Formation, players)
+	if err != nil {
+		fatal(err)
+	}
+	if rules.fullHalf && !subsAtHalfTime(schedule) {
+		fatal(fmt.Errorf("replan: -keeper-half needs a period that starts at half time"))
+	}
+
+	absent := map[string]bool{}
+	known := map[string]bool{}
+	for _, name := range roster {
+		known[name] = true
+	}
+	for _, name := range strings.Split(*absentList, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !known[name] {
+			fatal(fmt.Errorf("replan: %s is not in the schedule or roster", name))
+		}
+		absent[name] = true
+	}
+
+	balance := strength.rules(players)
+	revised, err := replanSchedule(schedule, roster, absent, *from-1, rules, balance, rand.New(rand.NewSource(*seed)))
+	if err != nil {
+		fatal(err)
+	}
+	warn(revised, append(rules.check(revised), balance.check(revised)...))
+
+	w := os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fatal(err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := lineup.WriteSchedule(w, revised, revised.Timed); err != nil {
+		fatal(err)
+	}
+
+	playingTime := lineup.CountPlayingTime(revised)
+	for _, name := range roster {
+		note := ""
+		if absent[name] {
+			note = " (absent)"
+		}
+		fmt.Fprintf(os.Stderr, "%-12s %s%s\n", name, lineup.FormatClock(playingTime.Total(name)), note)
+	}
+}
+
+// replanSchedule rebuilds the schedule from period index from onwards
+// without the absent players. The periods before it are kept and count
+// towards each player's time, so whoever has played least so far goes on
+// first. Period times are unchanged. When players have skill ratings, the
+// replanned periods are balanced as plan balances them.
+func replanSchedule(s *lineup.Schedule, roster []string, absent map[string]bool, from int, rules *keeperRules, strength *strengthRules, rng *rand.Rand) (*lineup.Schedule, error) {
+	if from < 0 || from >= len(s.Periods) {
+		return nil, fmt.Errorf("replan: -from must be between 1 and %d", len(s.Periods))
+	}
+
+	var available []string
+	for _, name := range roster {
+		if !absent[name] {
+			available = append(available, name)
+		}
+	}
+	if len(available) < len(s.Formation.Slots) {
+		return nil, fmt.Errorf("replan: formation %s needs %d players but only %d are available",
+			s.Formation.Name, len(s.Formation.Slots), len(available))
+	}
+
+	for attempt := 0; attempt < planAttempts; attempt++ {
+		revised := *s
+		revised.Periods = append([]lineup.Period{}, s.Periods...)
+		p := newPlanner(available, s.Formation, rules, s.GameTime/2, rng)
+		for _, period := range s.Periods[:from] {
+			p.record(period.Players, period.Minutes())
+		}
+		for i := from; i < len(revised.Periods); i++ {
+			revised.Periods[i].Players = p.next(revised.Periods[i].Start, revised.Periods[i].End)
+		}
+		if balanced, ok := balanceSchedule(&revised, from, p.rules, strength); ok {
+			return balanced, nil
+		}
+	}
+	return nil, fmt.Errorf("replan: can't keep every replanned period's team strength within %.0f%% of the game's average; try a wider -strength-band",
+		strength.band*100)
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/bballant/modir/pkg/lineup"
+)
+
+// writeReport exports the playing time to path, as JSON if the file name
+// ends in .json and as CSV otherwise.
+func writeReport(path string, pt *lineup.PlayingTime) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if strings.HasSuffix(strings.ToLower(path), ".json") {
+		return pt.WriteJSON(f)
+	}
+	return pt.WriteCSV(f)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Player is one player on a roster and what is known about them.
+type Player struct {
+	name   string
+	noGK   bool               // never plays in goal
+	skill  float64            // overall skill rating, or 0 if not rated
+	skills map[string]float64 // skill rating in some of DEF, MID and FWD
+}
+
+// readRoster reads a roster file with one player per line. A name can be
+// followed by comma-separated attributes, like "Lynx, gk=no" for a player who
+// doesn't play in goal or "Puma, skill=4, fwd=5" for skill ratings. Blank
+// lines and lines starting with # are ignored.
+func readRoster(path string) ([]Player, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var roster []Player
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		p, err := parsePlayer(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		if seen[p.name] {
+			return nil, fmt.Errorf("%s:%d: %s is listed more than once", path, line, p.name)
+		}
+		seen[p.name] = true
+		roster = append(roster, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return roster, nil
+}
+
+// parsePlayer reads a roster line: a name and then any attributes.
+func parsePlayer(text string) (Player, error) {
+	fields := strings.Split(text, ",")
+	p := Player{name: strings.TrimSpace(fields[0])}
+	if p.name == "" {
+		return p, fmt.Errorf("missing player name")
+	}
+	for _, attr := range fields[1:] {
+		key, value, _ := strings.Cut(strings.TrimSpace(attr), "=")
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.ToLower(strings.TrimSpace(value))
+		switch key {
+		case "gk":
+			switch value {
+			case "yes":
+				p.noGK = false
+			case "no":
+				p.noGK = true
+			default:
+				return p, fmt.Errorf("%s: gk must be yes or no, not %q", p.name, value)
+			}
+		case "skill", "def", "mid", "fwd":
+			rating, err := strconv.ParseFloat(value, 64)
+			if err != nil || rating <= 0 {
+				return p, fmt.Errorf("%s: %s must be a number above 0, not %q", p.name, key, value)
+			}
+			if key == "skill" {
+				p.skill = rating
+				continue
+			}
+			if p.skills == nil {
+				p.skills = map[string]float64{}
+			}
+			p.skills[strings.ToUpper(key)] = rating
+		default:
+			return p, fmt.Errorf("%s: unknown attribute %q", p.name, key)
+		}
+	}
+	return p, nil
+}
+
+// overallSkill is the player's skill rating, or the average of their
+// ratings for each line if they only have those. It is false for a player
+// with no ratings.
+func (p Player) overallSkill() (float64, bool) {
+	if p.skill > 0 {
+		return p.skill, true
+	}
+	if len(p.skills) == 0 {
+		return 0, false
+	}
+	total := 0.0
+	for _, rating := range p.skills {
+		total += rating
+	}
+	return total / float64(len(p.skills)), true
+}
+
+// playerNames lists the names of the players on a roster.
+func playerNames(roster []Player) []string {
+	names := make([]string, len(roster))
+	for i, p := range roster {
+		names[i] = p.name
+	}
+	return names
+}
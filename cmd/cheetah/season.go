@@ -0,0 +1,344 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"image/color"
+	"io/fs"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/bballant/modir/pkg/lineup"
+)
+
+// Ledger is the playing time of every game in a season, kept in a JSON file
+// between runs.
+type Ledger struct {
+	Games []LedgerGame `json:"games"`
+}
+
+// LedgerGame is one game's playing time per player.
+type LedgerGame struct {
+	Name      string                 `json:"name"`
+	Date      string                 `json:"date,omitempty"` // YYYY-MM-DD, what the games are ordered by
+	Formation string                 `json:"formation"`
+	Minutes   float64                `json:"minutes"`
+	Players   map[string]LedgerEntry `json:"players"`
+}
+
+// LedgerEntry is the minutes one player played in a game, by line and by
+// slot symbol.
+type LedgerEntry struct {
+	Total float64            `json:"total"`
+	Lines map[string]float64 `json:"lines"`
+	Slots map[string]float64 `json:"slots"`
+}
+
+func readLedger(path string) (*Ledger, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Ledger{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var l Ledger
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return &l, nil
+}
+
+func (l *Ledger) write(path string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// dateLayout is how game dates are written in the ledger and on the
+// command line. Dates in this layout sort as strings.
+const dateLayout = "2006-01-02"
+
+// parseDate checks that date is a YYYY-MM-DD date.
+func parseDate(date string) error {
+	if _, err := time.Parse(dateLayout, date); err != nil {
+		return fmt.Errorf("bad date %q, want YYYY-MM-DD", date)
+	}
+	return nil
+}
+
+// find returns the game recorded under name, or nil.
+func (l *Ledger) find(name string) *LedgerGame {
+	for i := range l.Games {
+		if l.Games[i].Name == name {
+			return &l.Games[i]
+		}
+	}
+	return nil
+}
+
+// previous returns the last game played on or before date, other than the
+// game called name, or nil if there isn't one.
+func (l *Ledger) previous(name, date string) *LedgerGame {
+	var last *LedgerGame
+	for i := range l.Games {
+		g := &l.Games[i]
+		if g.Name != name && g.Date <= date {
+			last = g
+		}
+	}
+	return last
+}
+
+// add records a game's schedule under name, played on date, replacing any
+// game already recorded with that name. An empty date keeps the replaced
+// game's date. The games are kept in date order.
+func (l *Ledger) add(name, date string, s *lineup.Schedule) {
+	if old := l.find(name); old != nil && date == "" {
+		date = old.Date
+	}
+	pt := lineup.CountPlayingTime(s)
+	game := LedgerGame{
+		Name:      name,
+		Date:      date,
+		Formation: s.Formation.Name,
+		Minutes:   s.GameTime,
+		Players:   map[string]LedgerEntry{},
+	}
+	for _, player := range pt.Players() {
+		game.Players[player] = LedgerEntry{
+			Total: pt.Total(player),
+			Lines: pt.ByLine(player),
+			Slots: pt.BySlot(player),
+		}
+	}
+
+	if old := l.find(name); old != nil {
+		*old = game
+	} else {
+		l.Games = append(l.Games, game)
+	}
+	sort.SliceStable(l.Games, func(i, j int) bool { return l.Games[i].Date < l.Games[j].Date })
+}
+
+// seasonTotal is one player's playing time over the season.
+type seasonTotal struct {
+	player  string
+	games   int
+	minutes float64
+	lines   map[string]float64
+}
+
+// totals adds up each player's minutes over every game, most behind the
+// team average first, and returns the average.
+func (l *Ledger) totals() ([]seasonTotal, float64) {
+	byPlayer := map[string]*seasonTotal{}
+	var totals []*seasonTotal
+	for _, g := range l.Games {
+		for player, e := range g.Players {
+			t, ok := byPlayer[player]
+			if !ok {
+				t = &seasonTotal{player: player, lines: map[string]float64{}}
+				byPlayer[player] = t
+				totals = append(totals, t)
+			}
+			t.games++
+			t.minutes += e.Total
+			for line, m := range e.Lines {
+				t.lines[line] += m
+			}
+		}
+	}
+
+	sum := 0.0
+	result := make([]seasonTotal, len(totals))
+	for i, t := range totals {
+		sum += t.minutes
+		result[i] = *t
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].minutes != result[j].minutes {
+			return result[i].minutes < result[j].minutes
+		}
+		return result[i].player < result[j].player
+	})
+
+	average := 0.0
+	if len(result) > 0 {
+		average = sum / float64(len(result))
+	}
+	return result, average
+}
+
+func runSeason(args []string) {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage: cheetah season add [flags] [game.csv]")
+		fmt.Fprintln(os.Stderr, "       cheetah season report [flags]")
+		os.Exit(2)
+	}
+	if len(args) == 0 {
+		usage()
+	}
+
+	switch args[0] {
+	case "add":
+		runSeasonAdd(args[1:])
+	case "report":
+		runSeasonReport(args[1:])
+	default:
+		usage()
+	}
+}
+
+func runSeasonAdd(args []string) {
+	flags := flag.NewFlagSet("cheetah season add", flag.ExitOnError)
+	ledgerFile := flags.String("ledger", "season.json", "Season ledger file")
+	gameName := flags.String("game", "", "Name for the game, e.g. its date; adding a game again replaces it")
+	date := flags.String("date", "", "Date the game was played, YYYY-MM-DD (default today, or the date it was added with before)")
+	game := addGameFlags(flags)
+	flags.Parse(args)
+
+	if *gameName == "" {
+		fatal(fmt.Errorf("season add: -game is required"))
+	}
+	if *date != "" {
+		if err := parseDate(*date); err != nil {
+			fatal(fmt.Errorf("season add: %v", err))
+		}
+	}
+
+	var schedule *lineup.Schedule
+	var err error
+	if flags.NArg() > 0 {
+		schedule, err = game.readScheduleFile(flags.Arg(0))
+	} else {
+		schedule, err = game.readSchedule(os.Stdin)
+	}
+	if err != nil {
+		fatal(err)
+	}
+
+	ledger, err := readLedger(*ledgerFile)
+	if err != nil {
+		fatal(err)
+	}
+	if *date == "" && ledger.find(*gameName) == nil {
+		*date = time.Now().Format(dateLayout)
+	}
+	ledger.add(*gameName, *date, schedule)
+	if err := ledger.write(*ledgerFile); err != nil {
+		fatal(err)
+	}
+	fmt.Printf("%s: %d games\n", *ledgerFile, len(ledger.Games))
+}
+
+func runSeasonReport(args []string) {
+	flags := flag.NewFlagSet("cheetah season report", flag.ExitOnError)
+	ledgerFile := flags.String("ledger", "season.json", "Season ledger file")
+	output := flags.String("o", "", "File to draw the season chart to (default season_chart.png, .pdf or .svg)")
+	format := flags.String("format", "", "Output format: png, pdf or svg (default from the -o file's extension, or png)")
+	paper := flags.String("paper", "letter", "Paper size for PDF output: letter or a4")
+	flags.Parse(args)
+
+	chartFormat, err := outputFormat(*format, *output, canvasFormats, "png")
+	if err != nil {
+		fatal(err)
+	}
+	ledger, err := readLedger(*ledgerFile)
+	if err != nil {
+		fatal(err)
+	}
+	if len(ledger.Games) == 0 {
+		fatal(fmt.Errorf("%s has no games, add some with cheetah season add", *ledgerFile))
+	}
+
+	totals, average := ledger.totals()
+	for _, line := range seasonTable(totals, average) {
+		fmt.Println(line)
+	}
+
+	if *output == "" {
+		*output = "season_chart." + chartFormat
+	}
+	c, err := newCanvas(chartFormat, *output, *paper)
+	if err != nil {
+		fatal(err)
+	}
+	drawSeasonChart(c, len(ledger.Games), totals, average)
+	if err := c.Close(); err != nil {
+		fatal(err)
+	}
+}
+
+func seasonTable(totals []seasonTotal, average float64) []string {
+	nameLen := len("Player")
+	for _, t := range totals {
+		if len(t.player) > nameLen {
+			nameLen = len(t.player)
+		}
+	}
+
+	heading := fmt.Sprintf("%-*s  Games  Minutes   vs avg", nameLen, "Player")
+	for _, line := range lineup.Lines {
+		heading += fmt.Sprintf("  %6s", line)
+	}
+	table := []string{heading}
+	for _, t := range totals {
+		row := fmt.Sprintf("%-*s  %5d  %7s  %7s", nameLen, t.player, t.games,
+			lineup.FormatClock(t.minutes), signedTimeString(t.minutes-average))
+		for _, line := range lineup.Lines {
+			row += fmt.Sprintf("  %6s", lineup.FormatClock(t.lines[line]))
+		}
+		table = append(table, row)
+	}
+	return append(table, fmt.Sprintf("Team average %s", lineup.FormatClock(average)))
+}
+
+// drawSeasonChart draws a bar of total minutes for each player, with a line
+// at the team average.
+func drawSeasonChart(c lineup.Canvas, games int, totals []seasonTotal, average float64) {
+	barLeft, barWidth := 200, 600
+	rowHeight, top := 30, 60
+
+	maxMinutes := average
+	for _, t := range totals {
+		if t.minutes > maxMinutes {
+			maxMinutes = t.minutes
+		}
+	}
+
+	c.NewPage(barLeft+barWidth+200, top+rowHeight*len(totals)+60)
+	drawChanges(c, 10, 30, []string{fmt.Sprintf("Season minutes, %d games", games)})
+
+	scale := float64(barWidth) / maxMinutes
+	avgX := barLeft + int(average*scale)
+	bottom := top + rowHeight*len(totals)
+	c.Line(color.Black, 2, avgX, top-10, avgX, bottom+10)
+	addLabel(c, "average "+lineup.FormatClock(average), avgX-60, bottom+30)
+
+	for i, t := range totals {
+		y := top + i*rowHeight
+		drawChanges(c, 10, y+20, []string{t.player})
+		barColor := color.Gray{Y: 128}
+		if t.minutes < average {
+			barColor = color.Gray{Y: 192}
+		}
+		c.Rect(barColor, barLeft, y+4, int(t.minutes*scale), rowHeight-8)
+		addLabel(c, fmt.Sprintf("%s (%s)", lineup.FormatClock(t.minutes), signedTimeString(t.minutes-average)),
+			barLeft+int(t.minutes*scale)+8, y+20)
+	}
+}
+
+// signedTimeString is like lineup.FormatClock but always shows the sign.
+func signedTimeString(minutes float64) string {
+	if minutes < 0 {
+		return "-" + lineup.FormatClock(-minutes)
+	}
+	return "+" + lineup.FormatClock(minutes)
+}
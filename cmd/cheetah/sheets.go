@@ -0,0 +1,197 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/color"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bballant/modir/pkg/lineup"
+)
+
+func runSheets(args []string) {
+	flags := flag.NewFlagSet("cheetah sheets", flag.ExitOnError)
+	game := addGameFlags(flags)
+	rosterFile := flags.String("roster", "", "Roster file, for players who aren't in the schedule")
+	format := flags.String("format", "", "Output format: text or md for messages, png, pdf or svg for printable cards (default from the -o file's extension, or text)")
+	output := flags.String("o", "", "File to write to (default stdout for text and md, player_sheets.png, .pdf or .svg for cards)")
+	paper := flags.String("paper", "letter", "Paper size for PDF output: letter or a4")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: cheetah sheets [flags] [schedule.csv]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	var schedule *lineup.Schedule
+	var err error
+	if flags.NArg() > 0 {
+		schedule, err = game.readScheduleFile(flags.Arg(0))
+	} else {
+		schedule, err = game.readSchedule(os.Stdin)
+	}
+	if err != nil {
+		fatal(err)
+	}
+	roster := schedule.Players()
+	if *rosterFile != "" {
+		players, err := readRoster(*rosterFile)
+		if err != nil {
+			fatal(err)
+		}
+		roster = lineup.MergeNames(roster, playerNames(players))
+	}
+
+	sheetFormat, err := outputFormat(*format, *output, sheetFormats, "text")
+	if err != nil {
+		fatal(err)
+	}
+
+	switch sheetFormat {
+	case "text", "md":
+		w := os.Stdout
+		if *output != "" {
+			f, err := os.Create(*output)
+			if err != nil {
+				fatal(err)
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := writeSheets(w, schedule, roster, sheetFormat == "md"); err != nil {
+			fatal(err)
+		}
+	default:
+		if *output == "" {
+			*output = "player_sheets." + sheetFormat
+		}
+		c, err := newCanvas(sheetFormat, *output, *paper)
+		if err != nil {
+			fatal(err)
+		}
+		drawSheets(c, schedule, roster)
+		if err := c.Close(); err != nil {
+			fatal(err)
+		}
+	}
+}
+
+// sheetFormats are the formats sheets can be written in, by file extension.
+var sheetFormats = map[string]string{"txt": "text", "md": "md", "png": "png", "pdf": "pdf", "svg": "svg"}
+
+// stint is a stretch of the game a player spends in one slot, or on the
+// bench when slot is "".
+type stint struct {
+	slot       string
+	start, end float64
+}
+
+func (s stint) place() string {
+	if s.slot == "" {
+		return "bench"
+	}
+	return s.slot
+}
+
+// playerStints follows one player through the schedule, joining periods in
+// the same place together.
+func playerStints(s *lineup.Schedule, name string) []stint {
+	var stints []stint
+	for _, p := range s.Periods {
+		slot := ""
+		for i, player := range p.Players {
+			if player == name {
+				slot = s.Formation.Slots[i].Symbol
+			}
+		}
+		if n := len(stints); n > 0 && stints[n-1].slot == slot {
+			stints[n-1].end = p.End
+			continue
+		}
+		stints = append(stints, stint{slot, p.Start, p.End})
+	}
+	return stints
+}
+
+// playingMinutes adds up the time in stints that isn't on the bench.
+func playingMinutes(stints []stint) float64 {
+	total := 0.0
+	for _, s := range stints {
+		if s.slot != "" {
+			total += s.end - s.start
+		}
+	}
+	return total
+}
+
+// writeSheets writes each player's timeline, one line per player that can
+// be pasted into a message, or as Markdown with a section per player.
+func writeSheets(w io.Writer, s *lineup.Schedule, roster []string, markdown bool) error {
+	var b strings.Builder
+	for i, name := range roster {
+		stints := playerStints(s, name)
+		if !markdown {
+			var parts []string
+			for _, st := range stints {
+				parts = append(parts, fmt.Sprintf("%s %s–%s", st.place(), lineup.FormatClock(st.start), lineup.FormatClock(st.end)))
+			}
+			fmt.Fprintf(&b, "%s — %s\n", name, strings.Join(parts, ", "))
+			continue
+		}
+
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "## %s\n\n", name)
+		fmt.Fprintf(&b, "Playing time %s\n\n", lineup.FormatClock(playingMinutes(stints)))
+		for _, st := range stints {
+			fmt.Fprintf(&b, "- %s–%s %s\n", lineup.FormatClock(st.start), lineup.FormatClock(st.end), st.place())
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// drawSheets draws a card for each player with their timeline, three cards
+// across, to be cut out and handed to families.
+func drawSheets(c lineup.Canvas, s *lineup.Schedule, roster []string) {
+	cardWidth, cols, margin := 320, 3, 20
+	lineHeight := 18
+
+	var timelines [][]stint
+	most := 0
+	for _, name := range roster {
+		stints := playerStints(s, name)
+		timelines = append(timelines, stints)
+		if len(stints) > most {
+			most = len(stints)
+		}
+	}
+	cardHeight := 70 + most*lineHeight
+	rows := (len(roster) + cols - 1) / cols
+
+	c.NewPage(cols*(cardWidth+margin)+margin, rows*(cardHeight+margin)+margin)
+	for i, name := range roster {
+		x := margin + (i%cols)*(cardWidth+margin)
+		y := margin + (i/cols)*(cardHeight+margin)
+
+		// Dotted cutting line around the card
+		for dx := 0; dx < cardWidth; dx += 8 {
+			c.Line(color.Gray{Y: 128}, 1, x+dx, y, x+dx+3, y)
+			c.Line(color.Gray{Y: 128}, 1, x+dx, y+cardHeight, x+dx+3, y+cardHeight)
+		}
+		for dy := 0; dy < cardHeight; dy += 8 {
+			c.Line(color.Gray{Y: 128}, 1, x, y+dy, x, y+dy+3)
+			c.Line(color.Gray{Y: 128}, 1, x+cardWidth, y+dy, x+cardWidth, y+dy+3)
+		}
+
+		stints := timelines[i]
+		drawChanges(c, x+10, y+25, []string{name})
+		addLabel(c, "Playing time "+lineup.FormatClock(playingMinutes(stints)), x+10, y+45)
+		for n, st := range stints {
+			addLabel(c, fmt.Sprintf("%s-%s  %s", lineup.FormatClock(st.start), lineup.FormatClock(st.end), st.place()),
+				x+10, y+70+n*lineHeight)
+		}
+	}
+}
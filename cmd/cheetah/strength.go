@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+
+	"github.com/bballant/modir/pkg/lineup"
+)
+
+// strengthFlags are the flags for keeping the team's strength even across
+// the game.
+type strengthFlags struct {
+	band *float64
+}
+
+func addStrengthFlags(flags *flag.FlagSet) *strengthFlags {
+	return &strengthFlags{
+		band: flags.Float64("strength-band", 15, "Percent a period's team strength may be above or below the game's average, when the roster has skill ratings"),
+	}
+}
+
+// strengthRules rate how strong a lineup is from the skill ratings on the
+// roster.
+type strengthRules struct {
+	overall map[string]float64            // rated players' overall skill
+	byLine  map[string]map[string]float64 // rated players' skill in each line they have a rating for
+	average float64                       // average overall skill, used for anyone unrated
+	band    float64                       // fraction a period may differ from the game's average
+}
+
+// rules collects the skill ratings from roster.
+func (f *strengthFlags) rules(roster []Player) *strengthRules {
+	r := &strengthRules{
+		overall: map[string]float64{},
+		byLine:  map[string]map[string]float64{},
+		band:    *f.band / 100,
+	}
+	total := 0.0
+	for _, p := range roster {
+		skill, ok := p.overallSkill()
+		if !ok {
+			continue
+		}
+		r.overall[p.name] = skill
+		r.byLine[p.name] = p.skills
+		total += skill
+	}
+	if len(r.overall) > 0 {
+		r.average = total / float64(len(r.overall))
+	}
+	return r
+}
+
+// rated reports whether anyone has a skill rating, without which there is
+// nothing to check.
+func (r *strengthRules) rated() bool {
+	return r != nil && len(r.overall) > 0
+}
+
+// rating is how good name is in line. Players rated for other lines but
+// not this one get their overall rating, and unrated players the team's
+// average.
+func (r *strengthRules) rating(name, line string) float64 {
+	if skill, ok := r.byLine[name][line]; ok {
+		return skill
+	}
+	if skill, ok := r.overall[name]; ok {
+		return skill
+	}
+	return r.average
+}
+
+// lineupStrength is the average rating of the players in row, each in the
+// line of the slot they play.
+func (r *strengthRules) lineupStrength(formation lineup.Formation, row []string) float64 {
+	total, n := 0.0, 0
+	for slot, name := range row {
+		if name == "" {
+			continue
+		}
+		total += r.rating(name, formation.Slots[slot].TeamLine())
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	return total / float64(n)
+}
+
+// inBand reports whether strength is close enough to target.
+func (r *strengthRules) inBand(strength, target float64) bool {
+	return math.Abs(strength-target) <= r.band*target+1e-9
+}
+
+// periods gives the team strength in each period of s, and the game's
+// average weighted by how long each period lasts.
+func (r *strengthRules) periods(s *lineup.Schedule) ([]float64, float64) {
+	strengths := make([]float64, len(s.Periods))
+	total, minutes := 0.0, 0.0
+	for i, p := range s.Periods {
+		strengths[i] = r.lineupStrength(s.Formation, p.Players)
+		total += strengths[i] * p.Minutes()
+		minutes += p.Minutes()
+	}
+	if minutes == 0 {
+		return strengths, 0
+	}
+	return strengths, total / minutes
+}
+
+// check finds the periods whose team is much stronger or weaker than the
+// rest of the game.
+func (r *strengthRules) check(s *lineup.Schedule) []problem {
+	if !r.rated() {
+		return nil
+	}
+
+	var problems []problem
+	strengths, average := r.periods(s)
+	for i, strength := range strengths {
+		if r.inBand(strength, average) {
+			continue
+		}
+		which, label := "above", "strong lineup"
+		if strength < average {
+			which, label = "below", "weak lineup"
+		}
+		problems = append(problems, problem{
+			period: i,
+			slot:   -1,
+			text: fmt.Sprintf("team strength %.1f is %.0f%% %s the game's average of %.1f",
+				strength, math.Abs(strength-average)/average*100, which, average),
+			label: fmt.Sprintf("%s (%.1f)", label, strength),
+		})
+	}
+	return problems
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"math"
+	"os"
+)
+
+// svgCanvas draws pages as SVG documents, one file per page like the PNG
+// backend. Text stays as text elements so it can be edited and searched.
+type svgCanvas struct {
+	name  string
+	pages []*svgPage
+	page  *svgPage
+}
+
+type svgPage struct {
+	width, height int
+	body          bytes.Buffer
+}
+
+func (c *svgCanvas) NewPage(width, height int) {
+	c.page = &svgPage{width: width, height: height}
+	c.pages = append(c.pages, c.page)
+}
+
+func (c *svgCanvas) Line(col color.Color, thickness, x1, y1, x2, y2 int) {
+	// The PNG backend thickens lines down and to the right of the points.
+	o := float64(thickness-1) / 2
+	fmt.Fprintf(&c.page.body, `<line x1="%g" y1="%g" x2="%g" y2="%g" stroke="%s" stroke-width="%d" stroke-linecap="square"/>`+"\n",
+		float64(x1)+o, float64(y1)+o, float64(x2)+o, float64(y2)+o, svgColor(col), thickness)
+}
+
+func (c *svgCanvas) Circle(col color.Color, x, y, r int, filled bool) {
+	if filled {
+		fmt.Fprintf(&c.page.body, `<circle cx="%d" cy="%d" r="%d" fill="%s"/>`+"\n", x, y, r, svgColor(col))
+	} else {
+		fmt.Fprintf(&c.page.body, `<circle cx="%d" cy="%d" r="%d" fill="none" stroke="%s"/>`+"\n", x, y, r, svgColor(col))
+	}
+}
+
+func (c *svgCanvas) Arc(col color.Color, x, y, r int, start, end float64) {
+	point := func(deg float64) (float64, float64) {
+		a := deg * math.Pi / 180
+		return float64(x) + float64(r)*math.Cos(a), float64(y) + float64(r)*math.Sin(a)
+	}
+	x1, y1 := point(start)
+	x2, y2 := point(end)
+	large := 0
+	if end-start > 180 {
+		large = 1
+	}
+	fmt.Fprintf(&c.page.body, `<path d="M %.2f %.2f A %d %d 0 %d 1 %.2f %.2f" fill="none" stroke="%s"/>`+"\n",
+		x1, y1, r, r, large, x2, y2, svgColor(col))
+}
+
+func (c *svgCanvas) Rect(col color.Color, x, y, width, height int) {
+	fmt.Fprintf(&c.page.body, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n", x, y, width, height, svgColor(col))
+}
+
+func (c *svgCanvas) Text(size float64, text string, x, y int) {
+	fmt.Fprintf(&c.page.body, `<text x="%d" y="%d" font-size="%g" xml:space="preserve">`, x, y, size)
+	xml.EscapeText(&c.page.body, []byte(text))
+	c.page.body.WriteString("</text>\n")
+}
+
+func (c *svgCanvas) Close() error {
+	for i, p := range c.pages {
+		var out bytes.Buffer
+		fmt.Fprintf(&out, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+		fmt.Fprintf(&out, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+			p.width, p.height, p.width, p.height)
+		fmt.Fprintf(&out, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+		out.WriteString(`<g font-family="Cousine, 'Courier New', monospace" font-weight="bold">` + "\n")
+		out.Write(p.body.Bytes())
+		out.WriteString("</g>\n</svg>\n")
+
+		if err := os.WriteFile(pageFileName(c.name, i, len(c.pages)), out.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func svgColor(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/bballant/modir/pkg/lineup"
+)
+
+func runTidy(args []string) {
+	flags := flag.NewFlagSet("cheetah tidy", flag.ExitOnError)
+	game := addGameFlags(flags)
+	rosterFile := flags.String("roster", "", "Roster file, to check who may play in goal and how strong each period is")
+	keeper := addKeeperFlags(flags)
+	strength := addStrengthFlags(flags)
+	tolerance := flags.Float64("tolerance", 3, "Most minutes any player's playing time may change by")
+	dryRun := flags.Bool("n", false, "Only list the stoppages, don't tidy the schedule")
+	output := flags.String("o", "", "File to write the tidied schedule CSV to (default stdout)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: cheetah tidy [flags] [schedule]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	var schedule *lineup.Schedule
+	var err error
+	if flags.NArg() > 0 {
+		schedule, err = game.readScheduleFile(flags.Arg(0))
+	} else {
+		schedule, err = game.readSchedule(os.Stdin)
+	}
+	if err != nil {
+		fatal(err)
+	}
+
+	var roster []Player
+	if *rosterFile != "" {
+		roster, err = readRoster(*rosterFile)
+		if err != nil {
+			fatal(err)
+		}
+	}
+	rules, err := keeper.rules(schedule.Formation, roster)
+	if err != nil {
+		fatal(err)
+	}
+
+	if *dryRun {
+		writeStoppages(os.Stdout, schedule)
+		return
+	}
+
+	tidied := tidySchedule(schedule, rules, strength.rules(roster), *tolerance)
+
+	w := os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fatal(err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := lineup.WriteSchedule(w, tidied, tidied.Timed); err != nil {
+		fatal(err)
+	}
+
+	// Report what changed on stderr so stdout can be piped straight into
+	// the renderer.
+	before, after := scheduleCost(schedule), scheduleCost(tidied)
+	fmt.Fprintf(os.Stderr, "%-16s %3d -> %d\n", "stoppages", before.stoppages, after.stoppages)
+	fmt.Fprintf(os.Stderr, "%-16s %3d -> %d\n", "position changes", before.changes, after.changes)
+	was, now := lineup.CountPlayingTime(schedule), lineup.CountPlayingTime(tidied)
+	for _, name := range schedule.Players() {
+		if math.Abs(was.Total(name)-now.Total(name)) > 1e-6 {
+			fmt.Fprintf(os.Stderr, "%-16s %s -> %s\n", name,
+				lineup.FormatClock(was.Total(name)), lineup.FormatClock(now.Total(name)))
+		}
+	}
+}
+
+// stoppage is a point in the game where the lineup changes.
+type stoppage struct {
+	period  int  // the period the new lineup plays in
+	changes int  // slots with a different player in them
+	atBreak bool // the change happens between halves or quarters
+}
+
+// stoppages lists every change of lineup in s.
+func stoppages(s *lineup.Schedule) []stoppage {
+	var stops []stoppage
+	for i := 1; i < len(s.Periods); i++ {
+		changes := lineup.Changes(s.Periods[i-1].Players, s.Periods[i].Players)
+		if len(changes) > 0 {
+			stops = append(stops, stoppage{period: i, changes: len(changes), atBreak: s.AtBreak(s.Periods[i].Start)})
+		}
+	}
+	return stops
+}
+
+// writeStoppages lists the stoppages in s, with when they happen and how
+// many positions change, and the totals.
+func writeStoppages(w io.Writer, s *lineup.Schedule) {
+	for _, stop := range stoppages(s) {
+		note := ""
+		if stop.atBreak {
+			note = " (at the break)"
+		}
+		fmt.Fprintf(w, "%-9s %2d changes%s\n", s.Clock(stop.period-1), stop.changes, note)
+	}
+	cost := scheduleCost(s)
+	fmt.Fprintf(w, "%d stoppages, %d position changes\n", cost.stoppages, cost.changes)
+}
+
+// tidyCost is what a schedule costs in interruptions to the game: the
+// stoppages outside the breaks, and every position that changes hands,
+// the "X for Y" lines on the field diagrams.
+type tidyCost struct {
+	stoppages int
+	changes   int
+}
+
+// scheduleCost adds up what s costs in interruptions.
+func scheduleCost(s *lineup.Schedule) tidyCost {
+	var cost tidyCost
+	for _, stop := range stoppages(s) {
+		if !stop.atBreak {
+			cost.stoppages++
+		}
+		cost.changes += stop.changes
+	}
+	return cost
+}
+
+// less reports whether c is cheaper than other. Fewer stoppages always
+// wins, since those are what the referee has to allow.
+func (c tidyCost) less(other tidyCost) bool {
+	if c.stoppages != other.stoppages {
+		return c.stoppages < other.stoppages
+	}
+	return c.changes < other.changes
+}
+
+// tidySchedule reduces the stoppages and position changes in s without any
+// player's minutes moving more than tolerance from what s gives them, or
+// breaking any more goalkeeper rules or leaving any more periods outside
+// the strength band than s already does. It repeatedly
+// makes whichever single change helps most: merging two periods into one,
+// moving a sub onto the nearest break, or swapping two positions.
+func tidySchedule(s *lineup.Schedule, rules *keeperRules, strength *strengthRules, tolerance float64) *lineup.Schedule {
+	players := s.Players()
+	minutes := lineup.CountPlayingTime(s)
+	keeperProblems := len(rules.check(s))
+	strengthProblems := len(strength.check(s))
+
+	allowed := func(t *lineup.Schedule) bool {
+		if len(rules.check(t)) > keeperProblems || len(strength.check(t)) > strengthProblems {
+			return false
+		}
+		pt := lineup.CountPlayingTime(t)
+		for _, name := range players {
+			if math.Abs(pt.Total(name)-minutes.Total(name)) > tolerance+1e-6 {
+				return false
+			}
+		}
+		return true
+	}
+
+	for {
+		best, bestCost := s, scheduleCost(s)
+		for _, t := range tidyMoves(s) {
+			if cost := scheduleCost(t); cost.less(bestCost) && allowed(t) {
+				best, bestCost = t, cost
+			}
+		}
+		if best == s {
+			return s
+		}
+		s = best
+	}
+}
+
+// tidyMoves lists the schedules one change away from s.
+func tidyMoves(s *lineup.Schedule) []*lineup.Schedule {
+	var moves []*lineup.Schedule
+	slots := len(s.Formation.Slots)
+	for i := 1; i < len(s.Periods); i++ {
+		// Play either lineup through both periods.
+		moves = append(moves, mergePeriods(s, i, i-1), mergePeriods(s, i, i))
+
+		// Make the sub at a break it is close to.
+		if t, ok := s.BreakBetween(s.Periods[i-1].Start, s.Periods[i].End); ok && !s.AtBreak(s.Periods[i].Start) {
+			m := copySchedule(s)
+			m.Periods[i-1].End, m.Periods[i].Start = t, t
+			m.Timed = true
+			moves = append(moves, m)
+		}
+
+		// Swap two positions from here on, which only changes this sub.
+		for a := 0; a < slots; a++ {
+			for b := a + 1; b < slots; b++ {
+				m := copySchedule(s)
+				for j := i; j < len(m.Periods); j++ {
+					swapSlots(&m.Periods[j], a, b)
+				}
+				moves = append(moves, m)
+			}
+		}
+	}
+
+	// Swap two positions for one period only.
+	for i := range s.Periods {
+		for a := 0; a < slots; a++ {
+			for b := a + 1; b < slots; b++ {
+				m := copySchedule(s)
+				swapSlots(&m.Periods[i], a, b)
+				moves = append(moves, m)
+			}
+		}
+	}
+	return moves
+}
+
+// mergePeriods joins periods i-1 and i into one, played by the lineup of
+// period keep.
+func mergePeriods(s *lineup.Schedule, i, keep int) *lineup.Schedule {
+	m := copySchedule(s)
+	merged := m.Periods[i-1]
+	merged.Players = m.Periods[keep].Players
+	merged.End = m.Periods[i].End
+	m.Periods = append(append(m.Periods[:i-1:i-1], merged), m.Periods[i+1:]...)
+	m.Timed = true
+	return m
+}
+
+func swapSlots(p *lineup.Period, a, b int) {
+	p.Players[a], p.Players[b] = p.Players[b], p.Players[a]
+}
+
+// copySchedule copies s deeply enough that its periods can be changed.
+func copySchedule(s *lineup.Schedule) *lineup.Schedule {
+	c := *s
+	c.Periods = make([]lineup.Period, len(s.Periods))
+	for i, p := range s.Periods {
+		p.Players = append([]string{}, p.Players...)
+		c.Periods[i] = p
+	}
+	return &c
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/bballant/modir/pkg/lineup"
+)
+
+// lineColors are the colors for each line of the team in charts.
+var lineColors = map[string]color.Color{
+	"GK":  color.RGBA{R: 240, G: 190, B: 50, A: 255},
+	"DEF": color.RGBA{R: 90, G: 140, B: 210, A: 255},
+	"MID": color.RGBA{R: 100, G: 180, B: 100, A: 255},
+	"FWD": color.RGBA{R: 220, G: 100, B: 90, A: 255},
+}
+
+// drawTimeline draws a row for each player with time across the page, and a
+// bar for every stretch they are on the field colored by the line they play
+// in. Bench time is left blank.
+func drawTimeline(c lineup.Canvas, s *lineup.Schedule, roster []string) {
+	nameWidth, chartWidth, totalWidth := 160, 800, 90
+	rowHeight, top := 28, 60
+	chartLeft := nameWidth
+	bottom := top + rowHeight*len(roster)
+	scale := float64(chartWidth) / s.GameTime
+	x := func(minutes float64) int { return chartLeft + int(math.Round(minutes*scale)) }
+
+	lineOf := map[string]string{}
+	for _, slot := range s.Formation.Slots {
+		lineOf[slot.Symbol] = slot.TeamLine()
+	}
+
+	c.NewPage(nameWidth+chartWidth+totalWidth+20, bottom+70)
+	drawChanges(c, 10, 25, []string{"Playing time"})
+
+	// Time axis, with a tick every five minutes and a line at each break
+	for m := 0.0; m <= s.GameTime; m += 5 {
+		c.Line(color.Gray{Y: 200}, 1, x(m), top-5, x(m), bottom)
+		addLabel(c, lineup.FormatClock(m), x(m)-20, top-10)
+	}
+	for seg := 1; seg < s.Segments; seg++ {
+		c.Line(color.Black, 2, x(float64(seg)*s.SegmentLength()), top-5, x(float64(seg)*s.SegmentLength()), bottom)
+	}
+
+	for i, name := range roster {
+		y := top + i*rowHeight
+		stints := playerStints(s, name)
+		drawChanges(c, 10, y+20, []string{name})
+		c.Line(color.Gray{Y: 200}, 1, chartLeft, y+rowHeight, chartLeft+chartWidth, y+rowHeight)
+		for _, st := range stints {
+			if st.slot == "" {
+				continue
+			}
+			left, right := x(st.start), x(st.end)
+			c.Rect(lineColors[lineOf[st.slot]], left+1, y+4, right-left-1, rowHeight-8)
+			if right-left > 8*len(st.slot)+4 {
+				addLabel(c, st.slot, left+4, y+19)
+			}
+		}
+		addLabel(c, lineup.FormatClock(playingMinutes(stints)), chartLeft+chartWidth+10, y+19)
+	}
+
+	// Legend
+	for i, line := range lineup.Lines {
+		lx := chartLeft + i*120
+		c.Rect(lineColors[line], lx, bottom+20, 20, 14)
+		addLabel(c, line, lx+26, bottom+32)
+	}
+}
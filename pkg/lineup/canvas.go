@@ -0,0 +1,19 @@
+package lineup
+
+import "image/color"
+
+// Canvas is what renderers draw a schedule on. Coordinates are pixels of
+// a PNG page, with the origin at the top left; canvases for other formats
+// scale them to fit. Text is drawn with its baseline at y.
+type Canvas interface {
+	NewPage(width, height int)
+	Line(c color.Color, thickness, x1, y1, x2, y2 int)
+	Circle(c color.Color, x, y, r int, filled bool)
+	// Arc draws part of a circle's outline, from start to end degrees
+	// clockwise from the positive x axis.
+	Arc(c color.Color, x, y, r int, start, end float64)
+	Rect(c color.Color, x, y, width, height int)
+	Text(size float64, text string, x, y int)
+	// Close writes out everything drawn so far.
+	Close() error
+}
@@ -0,0 +1,6 @@
+// Package lineup models substitution schedules for youth soccer: the
+// formations a team plays, who is in each slot of the formation from one
+// period of the game to the next, and how many minutes that gives each
+// player. It reads schedules from CSV, TSV, JSON and XLSX, works out the
+// subs between periods, and defines the Canvas that renderers draw on.
+package lineup
@@ -0,0 +1,156 @@
+package lineup
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+//go:embed formations.json
+var bundledFormations []byte
+
+// Formation is a named arrangement of players on the field.
+type Formation struct {
+	Name  string `json:"name"`
+	Slots []Slot `json:"slots"`
+}
+
+// Symbols lists the formation's slot symbols in order.
+func (f Formation) Symbols() []string {
+	symbols := make([]string, len(f.Slots))
+	for i, s := range f.Slots {
+		symbols[i] = s.Symbol
+	}
+	return symbols
+}
+
+// Slot is one place in a formation. X and Y are fractions of the field's
+// width and height, with the team's own goal on the left. Line is one of
+// Lines; when it is left out it is worked out from X.
+type Slot struct {
+	Symbol string  `json:"symbol"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Line   string  `json:"line,omitempty"`
+}
+
+// Lines are the parts of the team that playing time is broken down by.
+var Lines = []string{"GK", "DEF", "MID", "FWD"}
+
+// TeamLine is the line of the team the slot is in, one of Lines.
+func (s Slot) TeamLine() string {
+	switch {
+	case s.Line != "":
+		return s.Line
+	case s.X < 0.15:
+		return "GK"
+	case s.X < 0.375:
+		return "DEF"
+	case s.X < 0.625:
+		return "MID"
+	default:
+		return "FWD"
+	}
+}
+
+// LoadFormations returns the bundled formation library, with any formations
+// defined in the file at path added to it or replacing bundled ones of the
+// same name.
+func LoadFormations(path string) (map[string]Formation, error) {
+	formations := map[string]Formation{}
+
+	bundled, err := ParseFormations(bundledFormations)
+	if err != nil {
+		return nil, fmt.Errorf("bundled formations: %v", err)
+	}
+	for _, f := range bundled {
+		formations[formationKey(f.Name)] = f
+	}
+
+	if path == "" {
+		return formations, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	custom, err := ParseFormations(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for _, f := range custom {
+		formations[formationKey(f.Name)] = f
+	}
+
+	return formations, nil
+}
+
+// ParseFormations reads a JSON list of formations and checks that each one
+// makes sense.
+func ParseFormations(data []byte) ([]Formation, error) {
+	var formations []Formation
+	if err := json.Unmarshal(data, &formations); err != nil {
+		return nil, err
+	}
+
+	for _, f := range formations {
+		if f.Name == "" {
+			return nil, fmt.Errorf("formation with no name")
+		}
+		if len(f.Slots) == 0 {
+			return nil, fmt.Errorf("formation %s has no slots", f.Name)
+		}
+		seen := map[string]bool{}
+		for _, s := range f.Slots {
+			if s.Symbol == "" {
+				return nil, fmt.Errorf("formation %s has a slot with no symbol", f.Name)
+			}
+			if seen[s.Symbol] {
+				return nil, fmt.Errorf("formation %s has more than one %s slot", f.Name, s.Symbol)
+			}
+			seen[s.Symbol] = true
+			if s.X < 0 || s.X > 1 || s.Y < 0 || s.Y > 1 {
+				return nil, fmt.Errorf("formation %s slot %s is off the field", f.Name, s.Symbol)
+			}
+			if s.Line != "" && !isLine(s.Line) {
+				return nil, fmt.Errorf("formation %s slot %s has unknown line %s (want one of %s)",
+					f.Name, s.Symbol, s.Line, strings.Join(Lines, ", "))
+			}
+		}
+	}
+
+	return formations, nil
+}
+
+// FindFormation looks up a formation by name. Dashes are ignored, so "331"
+// and "3-3-1" name the same formation.
+func FindFormation(formations map[string]Formation, name string) (Formation, error) {
+	if f, ok := formations[formationKey(name)]; ok {
+		return f, nil
+	}
+
+	var names []string
+	for _, f := range formations {
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+
+	return Formation{}, fmt.Errorf("unknown formation %q (known formations: %s)", name, strings.Join(names, ", "))
+}
+
+func isLine(line string) bool {
+	for _, l := range Lines {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
+
+func formationKey(name string) string {
+	return strings.ReplaceAll(name, "-", "")
+}
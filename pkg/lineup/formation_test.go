@@ -0,0 +1,115 @@
+package lineup
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFormations(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		lines []string // of the first formation's slots
+		err   string
+	}{
+		{
+			name:  "lines from x",
+			data:  `[{"name": "1-1", "slots": [{"symbol": "GK", "x": 0.05, "y": 0.5}, {"symbol": "CB", "x": 0.3, "y": 0.5}, {"symbol": "ST", "x": 0.8, "y": 0.5}]}]`,
+			lines: []string{"GK", "DEF", "FWD"},
+		},
+		{
+			name:  "line given",
+			data:  `[{"name": "1-1", "slots": [{"symbol": "SW", "x": 0.1, "y": 0.5, "line": "DEF"}, {"symbol": "CM", "x": 0.5, "y": 0.5}]}]`,
+			lines: []string{"DEF", "MID"},
+		},
+		{
+			name: "not JSON",
+			data: `GK,LB,RB`,
+			err:  "invalid character",
+		},
+		{
+			name: "no name",
+			data: `[{"slots": [{"symbol": "GK", "x": 0.05, "y": 0.5}]}]`,
+			err:  "formation with no name",
+		},
+		{
+			name: "no slots",
+			data: `[{"name": "0-0"}]`,
+			err:  "formation 0-0 has no slots",
+		},
+		{
+			name: "no symbol",
+			data: `[{"name": "1-1", "slots": [{"x": 0.05, "y": 0.5}]}]`,
+			err:  "formation 1-1 has a slot with no symbol",
+		},
+		{
+			name: "same symbol twice",
+			data: `[{"name": "2", "slots": [{"symbol": "CB", "x": 0.3, "y": 0.3}, {"symbol": "CB", "x": 0.3, "y": 0.7}]}]`,
+			err:  "formation 2 has more than one CB slot",
+		},
+		{
+			name: "off the field",
+			data: `[{"name": "1", "slots": [{"symbol": "ST", "x": 1.2, "y": 0.5}]}]`,
+			err:  "formation 1 slot ST is off the field",
+		},
+		{
+			name: "unknown line",
+			data: `[{"name": "1", "slots": [{"symbol": "LW", "x": 0.8, "y": 0.2, "line": "WING"}]}]`,
+			err:  "formation 1 slot LW has unknown line WING (want one of GK, DEF, MID, FWD)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formations, err := ParseFormations([]byte(tt.data))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, s := range formations[0].Slots {
+				if s.TeamLine() != tt.lines[i] {
+					t.Errorf("slot %s is in line %s, want %s", s.Symbol, s.TeamLine(), tt.lines[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFindFormation(t *testing.T) {
+	formations, err := LoadFormations("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		want string
+		err  string
+	}{
+		{name: "3-3-1", want: "3-3-1"},
+		{name: "331", want: "3-3-1"},
+		{name: "4231", want: "4-2-3-1"},
+		{name: "42-31", want: "4-2-3-1"},
+		{name: "9-9", err: `unknown formation "9-9" (known formations: 1-2-1, 2-3-1,`},
+		{name: "", err: `unknown formation ""`},
+	}
+	for _, tt := range tests {
+		f, err := FindFormation(formations, tt.name)
+		if tt.err != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+				t.Errorf("FindFormation(%q) error = %v, want %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("FindFormation(%q): %v", tt.name, err)
+			continue
+		}
+		if f.Name != tt.want {
+			t.Errorf("FindFormation(%q) = %s, want %s", tt.name, f.Name, tt.want)
+		}
+	}
+}
@@ -0,0 +1,395 @@
+package lineup
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ReadRows reads all the records of a schedule, working out from its
+// contents whether it is CSV, TSV, JSON or an XLSX spreadsheet. Rows can
+// have any number of columns so that ParseSchedule can say which line is
+// wrong.
+func ReadRows(r io.Reader) ([][]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Excel's "CSV UTF-8" starts the file with a byte order mark.
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		return readXLSX(data)
+	case bytes.HasPrefix(trimmed, []byte("[")) || bytes.HasPrefix(trimmed, []byte("{")):
+		return readJSONRows(trimmed)
+	}
+
+	cr := csv.NewReader(bytes.NewReader(data))
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Contains(firstLine, []byte("\t")) {
+		cr.Comma = '\t'
+	}
+	cr.FieldsPerRecord = -1
+	return cr.ReadAll()
+}
+
+// readJSONRows reads a schedule written as a JSON list of periods, each an
+// object from position to player, like
+//
+//	[{"TIME": "00:00", "GK": "Serval", "LB": "Cougar", ...}, ...]
+//
+// or an object with the list under "periods". The header is every key in
+// the order they first appear; a period without one of them leaves it empty.
+func readJSONRows(data []byte) ([][]string, error) {
+	if data[0] == '{' {
+		var wrapper struct {
+			Periods json.RawMessage `json:"periods"`
+		}
+		if err := json.Unmarshal(data, &wrapper); err != nil {
+			return nil, err
+		}
+		if wrapper.Periods == nil {
+			return nil, fmt.Errorf(`JSON schedule needs a list of periods, or an object with one under "periods"`)
+		}
+		data = wrapper.Periods
+	}
+
+	var periods []orderedObject
+	if err := json.Unmarshal(data, &periods); err != nil {
+		return nil, err
+	}
+
+	var header []string
+	column := map[string]int{}
+	for _, p := range periods {
+		for _, key := range p.keys {
+			if _, ok := column[key]; !ok {
+				column[key] = len(header)
+				header = append(header, key)
+			}
+		}
+	}
+
+	rows := [][]string{header}
+	for _, p := range periods {
+		row := make([]string, len(header))
+		for i, key := range p.keys {
+			row[column[key]] = p.values[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// orderedObject is a JSON object of strings or numbers that remembers the
+// order of its keys.
+type orderedObject struct {
+	keys, values []string
+}
+
+func (o *orderedObject) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return fmt.Errorf("each period must be an object from position to player")
+	}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := t.(string)
+		t, err = dec.Token()
+		if err != nil {
+			return err
+		}
+		var value string
+		switch v := t.(type) {
+		case string:
+			value = v
+		case json.Number:
+			value = v.String()
+			// A number of minutes, like 6.5 for 06:30, as XLSX reads them.
+			if strings.EqualFold(strings.TrimSpace(key), TimeColumn) {
+				minutes, err := v.Float64()
+				if err != nil {
+					return fmt.Errorf("%s: bad number %s", key, v)
+				}
+				value = FormatClock(minutes)
+			}
+		case nil:
+		default:
+			return fmt.Errorf("%s: want a player name, not %v", key, t)
+		}
+		o.keys = append(o.keys, key)
+		o.values = append(o.values, value)
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// readXLSX reads the cells of the first sheet of an XLSX workbook as text.
+// Empty rows are skipped, as they are in CSV.
+func readXLSX(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	readXML := func(name string, v interface{}) error {
+		f, ok := files[name]
+		if !ok {
+			return fmt.Errorf("spreadsheet has no %s", name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		return xml.NewDecoder(rc).Decode(v)
+	}
+
+	// The first sheet in the workbook, and the file it is kept in
+	var workbook struct {
+		Sheets []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := readXML("xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, fmt.Errorf("spreadsheet has no sheets")
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := readXML("xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	sheetFile := ""
+	for _, rel := range rels.Relationships {
+		if rel.ID == workbook.Sheets[0].ID {
+			sheetFile = rel.Target
+			if strings.HasPrefix(sheetFile, "/") {
+				sheetFile = strings.TrimPrefix(sheetFile, "/")
+			} else {
+				sheetFile = path.Join("xl", sheetFile)
+			}
+		}
+	}
+
+	var shared struct {
+		Items []struct {
+			Text string `xml:",innerxml"`
+		} `xml:"si"`
+	}
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := readXML("xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+	sharedText := func(i int) (string, error) {
+		if i < 0 || i >= len(shared.Items) {
+			return "", fmt.Errorf("spreadsheet refers to missing shared string %d", i)
+		}
+		return xmlText(shared.Items[i].Text)
+	}
+
+	// The number formats of the cell styles, to tell times from plain
+	// numbers.
+	var styles struct {
+		NumFmts []struct {
+			ID   int    `xml:"numFmtId,attr"`
+			Code string `xml:"formatCode,attr"`
+		} `xml:"numFmts>numFmt"`
+		CellXfs []struct {
+			NumFmtID int `xml:"numFmtId,attr"`
+		} `xml:"cellXfs>xf"`
+	}
+	if _, ok := files["xl/styles.xml"]; ok {
+		if err := readXML("xl/styles.xml", &styles); err != nil {
+			return nil, err
+		}
+	}
+	timeStyle := func(i int) bool {
+		if i < 0 || i >= len(styles.CellXfs) {
+			return false
+		}
+		id := styles.CellXfs[i].NumFmtID
+		for _, f := range styles.NumFmts {
+			if f.ID == id {
+				return isTimeFormat(f.Code)
+			}
+		}
+		// Excel's built in time formats, like h:mm and mm:ss
+		return id >= 18 && id <= 21 || id >= 45 && id <= 47
+	}
+
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Style  int    `xml:"s,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:",innerxml"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := readXML(sheetFile, &sheet); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	timeCol := -1 // the TIME column, once the header has been read
+	for _, r := range sheet.Rows {
+		var row []string
+		for i, c := range r.Cells {
+			col := columnIndex(c.Ref)
+			if col < 0 {
+				col = i
+			}
+			for len(row) <= col {
+				row = append(row, "")
+			}
+			switch c.Type {
+			case "s":
+				n, err := strconv.Atoi(c.Value)
+				if err != nil {
+					return nil, fmt.Errorf("cell %s: bad shared string %q", c.Ref, c.Value)
+				}
+				if row[col], err = sharedText(n); err != nil {
+					return nil, err
+				}
+			case "inlineStr":
+				text, err := xmlText(c.Inline)
+				if err != nil {
+					return nil, err
+				}
+				row[col] = text
+			default:
+				row[col] = c.Value
+				if col != timeCol || c.Type != "" && c.Type != "n" {
+					break
+				}
+				// Times are numbers of days, and other numbers are taken
+				// as minutes, like 6.5 for 06:30.
+				minutes, err := strconv.ParseFloat(c.Value, 64)
+				if err != nil {
+					return nil, fmt.Errorf("cell %s: bad number %q", c.Ref, c.Value)
+				}
+				if timeStyle(c.Style) {
+					minutes *= 24 * 60
+				}
+				row[col] = FormatClock(minutes)
+			}
+		}
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+		if rows == nil {
+			for col, name := range row {
+				if strings.EqualFold(strings.TrimSpace(name), TimeColumn) {
+					timeCol = col
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	// Rows end at their last filled cell, or at a cell that was formatted
+	// but left empty, so make them as wide as the header.
+	for i := 1; i < len(rows); i++ {
+		for len(rows[i]) < len(rows[0]) {
+			rows[i] = append(rows[i], "")
+		}
+		for len(rows[i]) > len(rows[0]) && rows[i][len(rows[i])-1] == "" {
+			rows[i] = rows[i][:len(rows[i])-1]
+		}
+	}
+	return rows, nil
+}
+
+// isTimeFormat reports whether a spreadsheet number format shows a time,
+// having hours or seconds outside any quoted text. Brackets hold colours
+// and conditions, or elapsed times like [mm].
+func isTimeFormat(code string) bool {
+	inQuote := false
+	for i := 0; i < len(code); i++ {
+		switch c := code[i]; {
+		case c == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '\\':
+			i++
+		case c == '[':
+			end := strings.IndexByte(code[i:], ']')
+			if end < 0 {
+				return false
+			}
+			if inside := code[i+1 : i+end]; inside != "" && strings.Trim(inside, "hHmMsS") == "" {
+				return true
+			}
+			i += end
+		case c == 'h' || c == 'H' || c == 's' || c == 'S':
+			return true
+		}
+	}
+	return false
+}
+
+// xmlText joins the text of all the <t> elements in an XML fragment, which
+// is how both plain and formatted strings are kept in a spreadsheet.
+func xmlText(fragment string) (string, error) {
+	dec := xml.NewDecoder(strings.NewReader(fragment))
+	var b strings.Builder
+	inText := false
+	for {
+		t, err := dec.Token()
+		if err == io.EOF {
+			return b.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := t.(type) {
+		case xml.StartElement:
+			inText = t.Name.Local == "t"
+		case xml.EndElement:
+			inText = false
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+}
+
+// columnIndex turns the column letters of a cell reference like C7 into a
+// 0-based index, or -1 if there are none.
+func columnIndex(ref string) int {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+	}
+	return col - 1
+}
@@ -0,0 +1,163 @@
+package lineup
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testXLSX builds a workbook with one sheet of the given <row> elements, a
+// shared string table holding "GK" and "Ann", and a style 1 that shows
+// numbers as [mm]:ss.
+func testXLSX(t *testing.T, rows string) []byte {
+	t.Helper()
+	const ns = `xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"`
+	files := []struct{ name, body string }{
+		{"xl/workbook.xml", `<workbook ` + ns + ` xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Game" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`},
+		{"xl/sharedStrings.xml", `<sst ` + ns + `><si><t>GK</t></si><si><r><t>An</t></r><r><t>n</t></r></si></sst>`},
+		{"xl/styles.xml", `<styleSheet ` + ns + `><numFmts><numFmt numFmtId="164" formatCode="[mm]:ss"/></numFmts>` +
+			`<cellXfs><xf numFmtId="0"/><xf numFmtId="164"/></cellXfs></styleSheet>`},
+		{"xl/worksheets/sheet1.xml", `<worksheet ` + ns + `><sheetData>` + rows + `</sheetData></worksheet>`},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(f.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadRows(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want [][]string
+		err  string
+	}{
+		{
+			name: "CSV",
+			data: []byte("GK,LB,RB\nAnn,Bo,\"Cy, Jr\"\nAnn,Di\n"),
+			want: [][]string{{"GK", "LB", "RB"}, {"Ann", "Bo", "Cy, Jr"}, {"Ann", "Di"}},
+		},
+		{
+			name: "CSV with a byte order mark",
+			data: []byte("\xef\xbb\xbfGK,LB\r\nAnn,Bo\r\n"),
+			want: [][]string{{"GK", "LB"}, {"Ann", "Bo"}},
+		},
+		{
+			name: "TSV",
+			data: []byte("TIME\tGK\tLB\n00:00\tAnn\tBo\n"),
+			want: [][]string{{"TIME", "GK", "LB"}, {"00:00", "Ann", "Bo"}},
+		},
+		{
+			name: "JSON list",
+			data: []byte(`[{"TIME": "00:00", "GK": "Ann", "LB": "Bo"}, {"LB": "Di", "TIME": 10, "RB": "Cy"}]`),
+			want: [][]string{{"TIME", "GK", "LB", "RB"}, {"00:00", "Ann", "Bo", ""}, {"10:00", "", "Di", "Cy"}},
+		},
+		{
+			name: "JSON numbers",
+			data: []byte(`[{"time": 0, "GK": 7}, {"time": 6.5, "GK": 12}]`),
+			want: [][]string{{"time", "GK"}, {"00:00", "7"}, {"06:30", "12"}},
+		},
+		{
+			name: "JSON periods",
+			data: []byte("\xef\xbb\xbf\n {\"periods\": [{\"GK\": \"Ann\", \"LB\": null}]}"),
+			want: [][]string{{"GK", "LB"}, {"Ann", ""}},
+		},
+		{
+			name: "JSON object without periods",
+			data: []byte(`{"GK": "Ann"}`),
+			err:  `JSON schedule needs a list of periods`,
+		},
+		{
+			name: "JSON with a list for a player",
+			data: []byte(`[{"GK": ["Ann"]}]`),
+			err:  "GK: want a player name",
+		},
+		{
+			name: "XLSX",
+			data: testXLSX(t,
+				`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="inlineStr"><is><t>LB</t></is></c><c r="D1" t="inlineStr"><is><t>ST</t></is></c></row>`+
+					`<row r="2"/>`+
+					`<row r="3"><c r="A3" t="s"><v>1</v></c><c r="D3" t="inlineStr"><is><t>Di</t></is></c></row>`+
+					`<row r="4"><c r="B4" t="inlineStr"><is><t>Bo</t></is></c><c r="F4" s="1"/></row>`),
+			want: [][]string{{"GK", "LB", "", "ST"}, {"Ann", "", "", "Di"}, {"", "Bo", "", ""}},
+		},
+		{
+			name: "XLSX times",
+			data: testXLSX(t,
+				`<row r="1"><c r="A1" t="inlineStr"><is><t>Time</t></is></c><c r="B1" t="s"><v>0</v></c></row>`+
+					`<row r="2"><c r="A2" s="1"><v>0</v></c><c r="B2" t="s"><v>1</v></c></row>`+
+					`<row r="3"><c r="A3" s="1"><v>4.5138888888888885E-3</v></c><c r="B3" t="s"><v>1</v></c></row>`+
+					`<row r="4"><c r="A4" t="n"><v>12.5</v></c><c r="B4" t="s"><v>1</v></c></row>`+
+					`<row r="5"><c r="A5" t="inlineStr"><is><t>20:00</t></is></c><c r="B5" s="1"><v>3</v></c></row>`),
+			want: [][]string{{"Time", "GK"}, {"00:00", "Ann"}, {"06:30", "Ann"}, {"12:30", "Ann"}, {"20:00", "3"}},
+		},
+		{
+			name: "XLSX bad shared string",
+			data: testXLSX(t, `<row r="1"><c r="A1" t="s"><v>7</v></c></row>`),
+			err:  "spreadsheet refers to missing shared string 7",
+		},
+		{
+			name: "not a spreadsheet",
+			data: []byte("PK\x03\x04 not really a zip file"),
+			err:  "zip",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ReadRows(bytes.NewReader(tt.data))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(rows, tt.want) {
+				t.Errorf("got %q, want %q", rows, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsTimeFormat(t *testing.T) {
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"mm:ss", true},
+		{"[mm]:ss", true},
+		{"[h]:mm", true},
+		{"h:mm AM/PM", true},
+		{"General", false},
+		{"0.00", false},
+		{"yyyy-mm-dd", false},
+		{"[Red]0", false},
+		{`0 "hours"`, false},
+		{`0\h`, false},
+	}
+	for _, tt := range tests {
+		if got := isTimeFormat(tt.code); got != tt.want {
+			t.Errorf("isTimeFormat(%q) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
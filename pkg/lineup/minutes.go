@@ -0,0 +1,191 @@
+package lineup
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// PlayingTime is the minutes each player spent in each line of the
+// formation, and in each half or quarter when the game has them.
+type PlayingTime struct {
+	players   []string                      // in order of first appearance
+	minutes   map[string]map[string]float64 // by line
+	bySlot    map[string]map[string]float64 // by slot symbol
+	segments  []string                      // names of the halves or quarters, if any
+	bySegment map[string][]float64
+}
+
+func newPlayingTime() *PlayingTime {
+	return &PlayingTime{
+		minutes:   map[string]map[string]float64{},
+		bySlot:    map[string]map[string]float64{},
+		bySegment: map[string][]float64{},
+	}
+}
+
+// CountPlayingTime adds up the minutes for each player in the schedule.
+func CountPlayingTime(s *Schedule) *PlayingTime {
+	pt := newPlayingTime()
+	pt.segments = s.SegmentNames()
+	for i, p := range s.Periods {
+		for idx, name := range p.Players {
+			if name == "" {
+				continue // nobody in this slot
+			}
+			pt.add(name, s.Formation.Slots[idx], p.Minutes())
+		}
+		if pt.segments == nil {
+			continue
+		}
+		minutes := s.SegmentMinutes(i)
+		for _, name := range p.Players {
+			if name == "" {
+				continue
+			}
+			if pt.bySegment[name] == nil {
+				pt.bySegment[name] = make([]float64, len(pt.segments))
+			}
+			for seg, m := range minutes {
+				pt.bySegment[name][seg] += m
+			}
+		}
+	}
+	return pt
+}
+
+func (pt *PlayingTime) add(name string, slot Slot, minutes float64) {
+	if _, ok := pt.minutes[name]; !ok {
+		pt.players = append(pt.players, name)
+		pt.minutes[name] = map[string]float64{}
+		pt.bySlot[name] = map[string]float64{}
+	}
+	pt.minutes[name][slot.TeamLine()] += minutes
+	pt.bySlot[name][slot.Symbol] += minutes
+}
+
+// Players lists everyone who played, in the order they first appear.
+func (pt *PlayingTime) Players() []string {
+	return pt.players
+}
+
+// Total is how many minutes name played in all.
+func (pt *PlayingTime) Total(name string) float64 {
+	total := 0.0
+	for _, m := range pt.minutes[name] {
+		total += m
+	}
+	return total
+}
+
+// ByLine is the minutes name played in each of Lines. It must not be
+// changed.
+func (pt *PlayingTime) ByLine(name string) map[string]float64 {
+	return pt.minutes[name]
+}
+
+// BySlot is the minutes name played in each slot, by its symbol. It must
+// not be changed.
+func (pt *PlayingTime) BySlot(name string) map[string]float64 {
+	return pt.bySlot[name]
+}
+
+// Segments names the halves or quarters of the game, or is nil if it
+// isn't split up.
+func (pt *PlayingTime) Segments() []string {
+	return pt.segments
+}
+
+// InSegment is how many minutes name played in half or quarter seg.
+func (pt *PlayingTime) InSegment(name string, seg int) float64 {
+	if pt.bySegment[name] == nil {
+		return 0
+	}
+	return pt.bySegment[name][seg]
+}
+
+// Table lays out the minutes as fixed width lines of text, one per player,
+// under a heading.
+func (pt *PlayingTime) Table() []string {
+	nameLen := len("Player")
+	for _, name := range pt.players {
+		if len(name) > nameLen {
+			nameLen = len(name)
+		}
+	}
+
+	heading := fmt.Sprintf("%-*s", nameLen, "Player")
+	for _, line := range Lines {
+		heading += fmt.Sprintf("  %5s", line)
+	}
+	heading += "  Total"
+	for _, seg := range pt.segments {
+		heading += fmt.Sprintf("  %5s", seg)
+	}
+	table := []string{heading}
+
+	for _, name := range pt.players {
+		row := fmt.Sprintf("%-*s", nameLen, name)
+		for _, line := range Lines {
+			row += fmt.Sprintf("  %5s", FormatClock(pt.minutes[name][line]))
+		}
+		row += "  " + FormatClock(pt.Total(name))
+		for seg := range pt.segments {
+			row += "  " + FormatClock(pt.bySegment[name][seg])
+		}
+		table = append(table, row)
+	}
+
+	return table
+}
+
+type reportEntry struct {
+	Player   string             `json:"player"`
+	Minutes  map[string]float64 `json:"minutes"`
+	Total    float64            `json:"total"`
+	Segments map[string]float64 `json:"segments,omitempty"`
+}
+
+// WriteJSON writes the playing time as a JSON list with an entry for each
+// player.
+func (pt *PlayingTime) WriteJSON(w io.Writer) error {
+	var entries []reportEntry
+	for _, name := range pt.players {
+		minutes := map[string]float64{}
+		for _, line := range Lines {
+			minutes[line] = pt.minutes[name][line]
+		}
+		entry := reportEntry{Player: name, Minutes: minutes, Total: pt.Total(name)}
+		if pt.segments != nil {
+			entry.Segments = map[string]float64{}
+			for seg, segName := range pt.segments {
+				entry.Segments[segName] = pt.bySegment[name][seg]
+			}
+		}
+		entries = append(entries, entry)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+// WriteCSV writes the playing time as CSV with a row for each player.
+func (pt *PlayingTime) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	header := append(append([]string{"Player"}, Lines...), "Total")
+	cw.Write(append(header, pt.segments...))
+	for _, name := range pt.players {
+		record := []string{name}
+		for _, line := range Lines {
+			record = append(record, FormatClock(pt.minutes[name][line]))
+		}
+		record = append(record, FormatClock(pt.Total(name)))
+		for seg := range pt.segments {
+			record = append(record, FormatClock(pt.bySegment[name][seg]))
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,96 @@
+package lineup
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestCountPlayingTime(t *testing.T) {
+	// Di moves from ST to LB for Bo at 15:00, and Ed comes on up front.
+	periods := []Period{
+		{Players: []string{"Ann", "Bo", "Cy", "Di"}, Start: 0, End: 15},
+		{Players: []string{"Ann", "Di", "Cy", "Ed"}, Start: 15, End: 40},
+	}
+	type minutes struct {
+		total     float64
+		byLine    map[string]float64
+		bySegment []float64
+	}
+	tests := []struct {
+		name        string
+		segments    int
+		segmentTime float64
+		names       []string
+		want        map[string]minutes
+	}{
+		{
+			name:     "whole game",
+			segments: 1,
+			want: map[string]minutes{
+				"Ann": {40, map[string]float64{"GK": 40}, nil},
+				"Bo":  {15, map[string]float64{"DEF": 15}, nil},
+				"Di":  {40, map[string]float64{"DEF": 25, "FWD": 15}, nil},
+				"Ed":  {25, map[string]float64{"FWD": 25}, nil},
+			},
+		},
+		{
+			name:     "halves",
+			segments: 2,
+			names:    []string{"H1", "H2"},
+			want: map[string]minutes{
+				"Ann": {40, map[string]float64{"GK": 40}, []float64{20, 20}},
+				"Bo":  {15, map[string]float64{"DEF": 15}, []float64{15, 0}},
+				"Di":  {40, map[string]float64{"DEF": 25, "FWD": 15}, []float64{20, 20}},
+				"Ed":  {25, map[string]float64{"FWD": 25}, []float64{5, 20}},
+			},
+		},
+		{
+			name:        "halves shorter than the game",
+			segments:    2,
+			segmentTime: 15,
+			names:       []string{"H1", "H2"},
+			want: map[string]minutes{
+				"Ann": {40, map[string]float64{"GK": 40}, []float64{15, 25}},
+				"Bo":  {15, map[string]float64{"DEF": 15}, []float64{15, 0}},
+				"Ed":  {25, map[string]float64{"FWD": 25}, []float64{0, 25}},
+			},
+		},
+		{
+			name:     "quarters",
+			segments: 4,
+			names:    []string{"Q1", "Q2", "Q3", "Q4"},
+			want: map[string]minutes{
+				"Bo": {15, map[string]float64{"DEF": 15}, []float64{10, 5, 0, 0}},
+				"Cy": {40, map[string]float64{"DEF": 40}, []float64{10, 10, 10, 10}},
+				"Ed": {25, map[string]float64{"FWD": 25}, []float64{0, 5, 10, 10}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Schedule{Formation: testFormation, Periods: periods, GameTime: 40, Segments: tt.segments, SegmentTime: tt.segmentTime}
+			pt := CountPlayingTime(s)
+			if want := []string{"Ann", "Bo", "Cy", "Di", "Ed"}; !reflect.DeepEqual(pt.Players(), want) {
+				t.Errorf("Players() = %q, want %q", pt.Players(), want)
+			}
+			if !reflect.DeepEqual(pt.Segments(), tt.names) {
+				t.Errorf("Segments() = %q, want %q", pt.Segments(), tt.names)
+			}
+			for name, want := range tt.want {
+				if got := pt.Total(name); math.Abs(got-want.total) > 1e-9 {
+					t.Errorf("Total(%s) = %v, want %v", name, got, want.total)
+				}
+				if got := pt.ByLine(name); !reflect.DeepEqual(got, want.byLine) {
+					t.Errorf("ByLine(%s) = %v, want %v", name, got, want.byLine)
+				}
+				for seg, m := range want.bySegment {
+					if got := pt.InSegment(name, seg); math.Abs(got-m) > 1e-9 {
+						t.Errorf("InSegment(%s, %s) = %v, want %v", name, tt.names[seg], got, m)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package lineup
+
+// Position is where a slot of a formation is drawn, in pixels.
+type Position struct {
+	Symbol string
+	X, Y   int
+}
+
+// Positions places the formation's slots on a field drawn in the given
+// rectangle.
+func Positions(offsetX, offsetY, width, height int, formation Formation) []Position {
+	positions := make([]Position, len(formation.Slots))
+	for i, s := range formation.Slots {
+		positions[i] = Position{
+			Symbol: s.Symbol,
+			X:      int(s.X*float64(width)) + offsetX,
+			Y:      int(s.Y*float64(height)) + offsetY,
+		}
+	}
+	return positions
+}
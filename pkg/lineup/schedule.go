@@ -0,0 +1,337 @@
+package lineup
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// TimeColumn is the header of the optional column giving the minute each
+// period starts.
+const TimeColumn = "TIME"
+
+// Schedule is who plays in each slot of a formation, period by period.
+type Schedule struct {
+	Formation   Formation
+	Periods     []Period
+	GameTime    float64 // minutes
+	Segments    int     // halves or quarters the game is played in, or 1
+	SegmentTime float64 // minutes in each half or quarter, if not GameTime split evenly
+	Timed       bool    // period start times were given rather than split evenly
+}
+
+// Period is one stretch of the game between substitutions.
+type Period struct {
+	Players    []string // in the same order as Formation.Slots
+	Start, End float64  // minutes into the game
+	Line       int      // line of the input the period was read from
+}
+
+// Minutes is how long the period lasts.
+func (p Period) Minutes() float64 {
+	return p.End - p.Start
+}
+
+// ParseSchedule reads a schedule from CSV records. The first record names
+// the position in each column, which may come in any order but must match
+// the formation's slots one for one. An optional TIME column gives the
+// minute each period starts; without one the game is split evenly. The last
+// period ends at gameTime.
+func ParseSchedule(rows [][]string, formation Formation, gameTime float64) (*Schedule, error) {
+	if gameTime <= 0 {
+		return nil, fmt.Errorf("game length must be more than 0 minutes")
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("schedule is empty")
+	}
+
+	header := rows[0]
+	timeCol := -1
+	for col, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), TimeColumn) {
+			timeCol = col
+			header = append(append([]string{}, header[:col]...), header[col+1:]...)
+			break
+		}
+	}
+
+	columns, err := bindColumns(header, formation)
+	if err != nil {
+		return nil, fmt.Errorf("line 1: %v", err)
+	}
+
+	s := &Schedule{Formation: formation, GameTime: gameTime, Segments: 1, Timed: timeCol >= 0}
+	for i, row := range rows[1:] {
+		line := i + 2
+		if len(row) != len(rows[0]) {
+			return nil, fmt.Errorf("line %d: has %d columns, want %d", line, len(row), len(rows[0]))
+		}
+
+		p := Period{Players: make([]string, len(formation.Slots)), Line: line}
+		if timeCol >= 0 {
+			p.Start, err = ParseClock(row[timeCol])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			row = append(append([]string{}, row[:timeCol]...), row[timeCol+1:]...)
+		}
+		for col, name := range row {
+			p.Players[columns[col]] = strings.TrimSpace(name)
+		}
+		s.Periods = append(s.Periods, p)
+	}
+	if len(s.Periods) == 0 {
+		return nil, fmt.Errorf("schedule has no periods")
+	}
+
+	for i := range s.Periods {
+		p := &s.Periods[i]
+		if timeCol < 0 {
+			p.Start = TimeInGame(i, len(s.Periods), gameTime)
+		}
+		if i+1 < len(s.Periods) {
+			if timeCol < 0 {
+				p.End = TimeInGame(i+1, len(s.Periods), gameTime)
+			} else {
+				p.End = s.Periods[i+1].Start
+			}
+		} else {
+			p.End = gameTime
+		}
+	}
+
+	if timeCol >= 0 {
+		if s.Periods[0].Start != 0 {
+			return nil, fmt.Errorf("line %d: the first period must start at 00:00", s.Periods[0].Line)
+		}
+		for _, p := range s.Periods {
+			if p.End <= p.Start {
+				return nil, fmt.Errorf("line %d: period starting at %s must end after it starts, at %s",
+					p.Line, FormatClock(p.Start), FormatClock(p.End))
+			}
+		}
+	}
+
+	return s, nil
+}
+
+// SegmentNames are the short names of the halves or quarters of the game.
+func (s *Schedule) SegmentNames() []string {
+	switch s.Segments {
+	case 2:
+		return []string{"H1", "H2"}
+	case 4:
+		return []string{"Q1", "Q2", "Q3", "Q4"}
+	default:
+		return nil
+	}
+}
+
+// SegmentLength is how long each half or quarter lasts, in minutes.
+func (s *Schedule) SegmentLength() float64 {
+	if s.SegmentTime > 0 {
+		return s.SegmentTime
+	}
+	return s.GameTime / float64(s.Segments)
+}
+
+// SegmentAt is the index of the half or quarter that minute t falls in.
+func (s *Schedule) SegmentAt(t float64) int {
+	seg := int(t / s.SegmentLength())
+	if seg >= s.Segments {
+		seg = s.Segments - 1
+	}
+	return seg
+}
+
+// StartsSegment reports whether period i is the first one in a new half or
+// quarter, so the subs going into it happen at the break.
+func (s *Schedule) StartsSegment(i int) bool {
+	return i > 0 && s.SegmentAt(s.Periods[i].Start) != s.SegmentAt(s.Periods[i-1].Start)
+}
+
+// SegmentMinutes splits the minutes of period i between the halves or
+// quarters it overlaps. Any time past the end of the last one counts
+// towards it.
+func (s *Schedule) SegmentMinutes(i int) []float64 {
+	p := s.Periods[i]
+	minutes := make([]float64, s.Segments)
+	length := s.SegmentLength()
+	for seg := range minutes {
+		start := math.Max(p.Start, float64(seg)*length)
+		end := math.Min(p.End, float64(seg+1)*length)
+		if seg == len(minutes)-1 {
+			end = p.End
+		}
+		if end > start {
+			minutes[seg] = end - start
+		}
+	}
+	return minutes
+}
+
+// Clock is the time at the end of period i as shown on the game clock, which
+// starts again from zero each half or quarter.
+func (s *Schedule) Clock(i int) string {
+	p := s.Periods[i]
+	if s.Segments <= 1 {
+		return FormatClock(p.End)
+	}
+	seg := s.SegmentAt(p.Start)
+	return s.SegmentNames()[seg] + " " + FormatClock(p.End-float64(seg)*s.SegmentLength())
+}
+
+// ParseClock reads a time in the game written as minutes and seconds, like
+// 06:30, or as a number of minutes.
+func ParseClock(text string) (float64, error) {
+	text = strings.TrimSpace(text)
+	mins, secs, found := strings.Cut(text, ":")
+	m, err := strconv.Atoi(mins)
+	if err != nil || m < 0 {
+		return 0, fmt.Errorf("bad time %q, want minutes and seconds like 06:30", text)
+	}
+	if !found {
+		return float64(m), nil
+	}
+	sc, err := strconv.Atoi(secs)
+	if err != nil || sc < 0 || sc >= 60 {
+		return 0, fmt.Errorf("bad time %q, want minutes and seconds like 06:30", text)
+	}
+	return float64(m) + float64(sc)/60, nil
+}
+
+// bindColumns maps each column of the header to the index of the formation
+// slot with that symbol.
+func bindColumns(header []string, formation Formation) ([]int, error) {
+	slots := map[string]int{}
+	for i, s := range formation.Slots {
+		slots[strings.ToUpper(s.Symbol)] = i
+	}
+
+	columns := make([]int, len(header))
+	bound := make([]bool, len(formation.Slots))
+	for col, name := range header {
+		idx, ok := slots[strings.ToUpper(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("position %q is not in formation %s (%s)",
+				name, formation.Name, strings.Join(formation.Symbols(), ", "))
+		}
+		if bound[idx] {
+			return nil, fmt.Errorf("position %s is in more than one column", formation.Slots[idx].Symbol)
+		}
+		bound[idx] = true
+		columns[col] = idx
+	}
+
+	var missing []string
+	for i, ok := range bound {
+		if !ok {
+			missing = append(missing, formation.Slots[i].Symbol)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("formation %s positions missing from header: %s",
+			formation.Name, strings.Join(missing, ", "))
+	}
+
+	return columns, nil
+}
+
+// AtBreak reports whether minute t is the end of a half or quarter, when
+// the game stops anyway.
+func (s *Schedule) AtBreak(t float64) bool {
+	if s.Segments <= 1 {
+		return false
+	}
+	seg := t / s.SegmentLength()
+	return t > 0 && t < s.GameTime && math.Abs(seg-math.Round(seg)) < 1e-6
+}
+
+// BreakBetween finds a break strictly between minutes start and end.
+func (s *Schedule) BreakBetween(start, end float64) (float64, bool) {
+	for seg := 1; seg < s.Segments; seg++ {
+		t := float64(seg) * s.SegmentLength()
+		if t > start+1e-6 && t < end-1e-6 {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// Players lists everyone named in the schedule, in the order they first
+// appear.
+func (s *Schedule) Players() []string {
+	var names []string
+	for _, p := range s.Periods {
+		names = MergeNames(names, p.Players)
+	}
+	return names
+}
+
+// MergeNames appends the names in more that aren't already in names.
+func MergeNames(names, more []string) []string {
+	seen := map[string]bool{}
+	for _, name := range names {
+		seen[name] = true
+	}
+	for _, name := range more {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// EvenSchedule makes a schedule of rows that splits the game evenly.
+func EvenSchedule(formation Formation, rows [][]string, gameTime float64) *Schedule {
+	s := &Schedule{Formation: formation, GameTime: gameTime, Segments: 1}
+	for i, row := range rows {
+		s.Periods = append(s.Periods, Period{
+			Players: row,
+			Start:   TimeInGame(i, len(rows), gameTime),
+			End:     TimeInGame(i+1, len(rows), gameTime),
+		})
+	}
+	return s
+}
+
+// WriteSchedule writes the schedule as CSV with the formation's slot symbols
+// as the header row, and with a TIME column if withTimes is set.
+func WriteSchedule(w io.Writer, s *Schedule, withTimes bool) error {
+	header := s.Formation.Symbols()
+	if withTimes {
+		header = append([]string{TimeColumn}, header...)
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+	for _, p := range s.Periods {
+		record := p.Players
+		if withTimes {
+			record = append([]string{FormatClock(p.Start)}, record...)
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// TimeInGame is the minute the given period starts when the game is split
+// into totalPeriods of equal length.
+func TimeInGame(period int, totalPeriods int, totalTime float64) float64 {
+	timeNum := 0.0
+	if period > 0 {
+		timeNum = float64(period) / float64(totalPeriods)
+	}
+	return timeNum * totalTime
+}
+
+// FormatClock writes a number of minutes as minutes and seconds, like 06:30.
+func FormatClock(decimal float64) string {
+	seconds := int(math.Round(decimal * 60))
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
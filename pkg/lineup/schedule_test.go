@@ -0,0 +1,219 @@
+package lineup
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testFormation is a small formation with a slot in three of the lines.
+var testFormation = Formation{
+	Name: "2-1",
+	Slots: []Slot{
+		{Symbol: "GK", X: 0.05, Y: 0.5},
+		{Symbol: "LB", X: 0.25, Y: 0.25},
+		{Symbol: "RB", X: 0.25, Y: 0.75},
+		{Symbol: "ST", X: 0.75, Y: 0.5},
+	},
+}
+
+func TestParseSchedule(t *testing.T) {
+	type period struct {
+		players    []string
+		start, end float64
+	}
+	tests := []struct {
+		name     string
+		rows     [][]string
+		gameTime float64
+		want     []period
+		timed    bool
+		err      string
+	}{
+		{
+			name: "formation order",
+			rows: [][]string{
+				{"GK", "LB", "RB", "ST"},
+				{"Ann", "Bo", "Cy", "Di"},
+				{"Ann", "Ed", "Cy", "Bo"},
+			},
+			gameTime: 40,
+			want: []period{
+				{[]string{"Ann", "Bo", "Cy", "Di"}, 0, 20},
+				{[]string{"Ann", "Ed", "Cy", "Bo"}, 20, 40},
+			},
+		},
+		{
+			name: "any order and case",
+			rows: [][]string{
+				{"st", " RB", "GK ", "Lb"},
+				{"Di", "Cy", "Ann", " Bo "},
+			},
+			gameTime: 30,
+			want: []period{
+				{[]string{"Ann", "Bo", "Cy", "Di"}, 0, 30},
+			},
+		},
+		{
+			name: "empty slot",
+			rows: [][]string{
+				{"GK", "LB", "RB", "ST"},
+				{"Ann", "", "Cy", "Di"},
+			},
+			gameTime: 30,
+			want: []period{
+				{[]string{"Ann", "", "Cy", "Di"}, 0, 30},
+			},
+		},
+		{
+			name: "time column",
+			rows: [][]string{
+				{"GK", "LB", "time", "RB", "ST"},
+				{"Ann", "Bo", "00:00", "Cy", "Di"},
+				{"Ann", "Ed", "06:30", "Cy", "Di"},
+				{"Ed", "Bo", "25", "Cy", "Di"},
+			},
+			gameTime: 40,
+			want: []period{
+				{[]string{"Ann", "Bo", "Cy", "Di"}, 0, 6.5},
+				{[]string{"Ann", "Ed", "Cy", "Di"}, 6.5, 25},
+				{[]string{"Ed", "Bo", "Cy", "Di"}, 25, 40},
+			},
+			timed: true,
+		},
+		{
+			name:     "missing column",
+			rows:     [][]string{{"GK", "LB", "RB"}, {"Ann", "Bo", "Cy"}},
+			gameTime: 40,
+			err:      "line 1: formation 2-1 positions missing from header: ST",
+		},
+		{
+			name:     "unknown column",
+			rows:     [][]string{{"GK", "LB", "RB", "CM"}, {"Ann", "Bo", "Cy", "Di"}},
+			gameTime: 40,
+			err:      `line 1: position "CM" is not in formation 2-1 (GK, LB, RB, ST)`,
+		},
+		{
+			name:     "duplicate column",
+			rows:     [][]string{{"GK", "LB", "RB", "gk", "ST"}, {"Ann", "Bo", "Cy", "Di", "Ed"}},
+			gameTime: 40,
+			err:      "line 1: position GK is in more than one column",
+		},
+		{
+			name:     "short row",
+			rows:     [][]string{{"GK", "LB", "RB", "ST"}, {"Ann", "Bo", "Cy"}},
+			gameTime: 40,
+			err:      "line 2: has 3 columns, want 4",
+		},
+		{
+			name:     "bad seconds",
+			rows:     [][]string{{"TIME", "GK", "LB", "RB", "ST"}, {"00:00", "Ann", "Bo", "Cy", "Di"}, {"6:75", "Ann", "Bo", "Cy", "Di"}},
+			gameTime: 40,
+			err:      `line 3: bad time "6:75"`,
+		},
+		{
+			name:     "bad time",
+			rows:     [][]string{{"TIME", "GK", "LB", "RB", "ST"}, {"kickoff", "Ann", "Bo", "Cy", "Di"}},
+			gameTime: 40,
+			err:      `line 2: bad time "kickoff"`,
+		},
+		{
+			name:     "late start",
+			rows:     [][]string{{"TIME", "GK", "LB", "RB", "ST"}, {"01:00", "Ann", "Bo", "Cy", "Di"}},
+			gameTime: 40,
+			err:      "line 2: the first period must start at 00:00",
+		},
+		{
+			name:     "times out of order",
+			rows:     [][]string{{"TIME", "GK", "LB", "RB", "ST"}, {"00:00", "Ann", "Bo", "Cy", "Di"}, {"20:00", "Ann", "Bo", "Cy", "Di"}, {"10:00", "Ann", "Bo", "Cy", "Di"}},
+			gameTime: 40,
+			err:      "line 3: period starting at 20:00 must end after it starts, at 10:00",
+		},
+		{
+			name:     "time past the end",
+			rows:     [][]string{{"TIME", "GK", "LB", "RB", "ST"}, {"00:00", "Ann", "Bo", "Cy", "Di"}, {"45:00", "Ann", "Bo", "Cy", "Di"}},
+			gameTime: 40,
+			err:      "line 3: period starting at 45:00 must end after it starts, at 40:00",
+		},
+		{
+			name:     "no periods",
+			rows:     [][]string{{"GK", "LB", "RB", "ST"}},
+			gameTime: 40,
+			err:      "schedule has no periods",
+		},
+		{
+			name:     "empty",
+			gameTime: 40,
+			err:      "schedule is empty",
+		},
+		{
+			name:     "no game time",
+			rows:     [][]string{{"GK", "LB", "RB", "ST"}, {"Ann", "Bo", "Cy", "Di"}},
+			gameTime: 0,
+			err:      "game length must be more than 0 minutes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseSchedule(tt.rows, testFormation, tt.gameTime)
+			if tt.err != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.Timed != tt.timed {
+				t.Errorf("Timed = %v, want %v", s.Timed, tt.timed)
+			}
+			if len(s.Periods) != len(tt.want) {
+				t.Fatalf("got %d periods, want %d", len(s.Periods), len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := s.Periods[i]
+				if !reflect.DeepEqual(got.Players, want.players) {
+					t.Errorf("period %d players = %q, want %q", i, got.Players, want.players)
+				}
+				if math.Abs(got.Start-want.start) > 1e-9 || math.Abs(got.End-want.end) > 1e-9 {
+					t.Errorf("period %d runs %v to %v, want %v to %v", i, got.Start, got.End, want.start, want.end)
+				}
+				if got.Line != i+2 {
+					t.Errorf("period %d is from line %d, want %d", i, got.Line, i+2)
+				}
+			}
+		})
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	tests := []struct {
+		text string
+		want float64
+		err  bool
+	}{
+		{"00:00", 0, false},
+		{"06:30", 6.5, false},
+		{" 12:15 ", 12.25, false},
+		{"7", 7, false},
+		{"90:00", 90, false},
+		{"6:60", 0, true},
+		{"-1:00", 0, true},
+		{"1.5", 0, true},
+		{"", 0, true},
+		{"half", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseClock(tt.text)
+		if (err != nil) != tt.err {
+			t.Errorf("ParseClock(%q) error = %v, want error %v", tt.text, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseClock(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
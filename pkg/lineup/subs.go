@@ -0,0 +1,20 @@
+package lineup
+
+// Change is a player going on in place of another in one slot of the
+// formation. A player moving between slots is a change in each of them.
+type Change struct {
+	Slot    int
+	In, Out string
+}
+
+// Changes lists the slots that have a different player in next than in
+// prev, in slot order.
+func Changes(prev, next []string) []Change {
+	var changes []Change
+	for slot, name := range next {
+		if prev[slot] != name {
+			changes = append(changes, Change{Slot: slot, In: name, Out: prev[slot]})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,45 @@
+package lineup
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChanges(t *testing.T) {
+	tests := []struct {
+		name       string
+		prev, next []string
+		want       []Change
+	}{
+		{
+			name: "no change",
+			prev: []string{"Ann", "Bo", "Cy"},
+			next: []string{"Ann", "Bo", "Cy"},
+		},
+		{
+			name: "sub",
+			prev: []string{"Ann", "Bo", "Cy"},
+			next: []string{"Ann", "Di", "Cy"},
+			want: []Change{{Slot: 1, In: "Di", Out: "Bo"}},
+		},
+		{
+			name: "swap",
+			prev: []string{"Ann", "Bo", "Cy"},
+			next: []string{"Cy", "Bo", "Ann"},
+			want: []Change{{Slot: 0, In: "Cy", Out: "Ann"}, {Slot: 2, In: "Ann", Out: "Cy"}},
+		},
+		{
+			name: "empty slots",
+			prev: []string{"Ann", "", "Cy"},
+			next: []string{"", "Bo", "Cy"},
+			want: []Change{{Slot: 0, In: "", Out: "Ann"}, {Slot: 1, In: "Bo", Out: ""}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Changes(tt.prev, tt.next); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Changes(%q, %q) = %+v, want %+v", tt.prev, tt.next, got, tt.want)
+			}
+		})
+	}
+}